@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"miya/internal/memory"
+	"miya/internal/screen"
+	"miya/internal/vm"
+	"os"
+	"time"
+)
+
+func runBench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	cycles := flags.Uint64("cycles", 10000000, "Number of instructions to run")
+	ipf := flags.Uint64("ipf", 1000, "Instructions per frame, timers and inputs are updated between frames")
+	platformName := flags.String("platform", vm.PLATFORM_CHIP8, "Platform to run the rom on")
+	jit := flags.Bool("jit", false, "Run basic blocks as cached closures instead of interpreting every instruction")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: miya bench rom.ch8 [--cycles N] [--ipf N] [--platform name] [--jit]\n")
+		flags.PrintDefaults()
+	}
+
+	// NOTE: The rom comes first, so the flags after it are parsed too
+	if len(args) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	fname := args[0]
+	flags.Parse(args[1:])
+
+	buffer, err := os.ReadFile(fname)
+	if err != nil {
+		log.Fatalf("os.ReadFile(): %v\n", err)
+	}
+
+	platform := resolvePlatform(options{platform: *platformName})
+	machine := vm.NewVirtualMachine(memory.NewMemory(platform.MemorySize), memory.NewStack(platform.StackDepth), &screen.MockWindow{}, *ipf, false)
+	machine.SetSeed(0)
+	machine.SetJIT(*jit)
+
+	if err := machine.SetPlatform(platform); err != nil {
+		log.Fatalf("machine.SetPlatform(): %v\n", err)
+	}
+
+	if err := machine.LoadROM(buffer); err != nil {
+		log.Fatalf("machine.LoadROM(): %v\n", err)
+	}
+
+	// NOTE: Frames run back to back on a screen that draws nothing, so the time is spent in the interpreter only
+	start := time.Now()
+	for machine.Executed() < *cycles {
+		machine.RunFrame()
+	}
+	elapsed := time.Since(start)
+
+	fmt.Printf("Instructions: %d\n", machine.Executed())
+	fmt.Printf("Time:         %v\n", elapsed.Round(time.Microsecond))
+	fmt.Printf("Speed:        %.0f instructions/s\n", float64(machine.Executed())/elapsed.Seconds())
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"miya/internal/profile"
+	"miya/internal/vm"
+	"os"
+)
+
+func runCFG(args []string) {
+	flags := flag.NewFlagSet("cfg", flag.ExitOnError)
+	format := flags.String("format", "dot", "Output format: dot or json")
+	profileName := flags.String("profile", "", "Profile written by miya --profile, adds the execution count of every block")
+	loadAddress := loadAddressFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: miya cfg rom.ch8 [--format dot|json] [--profile miya.profile] [--platform name] [--load-address 0x200]\n")
+		flags.PrintDefaults()
+	}
+
+	// NOTE: The rom comes first, so the flags after it are parsed too
+	if len(args) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	fname := args[0]
+	flags.Parse(args[1:])
+
+	buffer, err := os.ReadFile(fname)
+	if err != nil {
+		log.Fatalf("os.ReadFile(): %v\n", err)
+	}
+
+	cfg := vm.BuildCFG(vm.Disassemble(buffer, loadAddress(vm.ROM_ADDRESS)))
+
+	if *profileName != "" {
+		p, err := profile.Load(*profileName)
+		if err != nil {
+			log.Fatalf("profile.Load(): %v\n", err)
+		}
+
+		cfg.MergeProfile(p)
+	}
+
+	switch *format {
+	case "dot":
+		err = cfg.WriteDOT(os.Stdout, *profileName != "")
+	case "json":
+		err = cfg.WriteJSON(os.Stdout)
+	default:
+		log.Fatalf("unknown format: %q, want dot or json\n", *format)
+	}
+
+	if err != nil {
+		log.Fatalf("cfg.Write(): %v\n", err)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"miya/internal/coverage"
+	"miya/internal/rom"
+	"miya/internal/vm"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	COVERAGE_HIT     = "hit"
+	COVERAGE_MISS    = "miss"
+	COVERAGE_PARTIAL = "partial"
+)
+
+type coverageLine struct {
+	Address  uint16
+	Opcode   uint16
+	Mnemonic string
+	Count    uint64
+	Skip     bool
+	Branch   coverage.Branch
+	Status   string
+}
+
+type coverageReport struct {
+	ROM           string
+	Lines         []coverageLine
+	Instructions  int
+	Hit           int
+	Directions    int
+	DirectionsHit int
+}
+
+var coverageTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage - {{.ROM}}</title>
+<style>
+body { font-family: monospace; }
+td { padding: 0 1em; }
+.hit { background: #c8f0c8; }
+.miss { background: #f0c8c8; }
+.partial { background: #f0e0a0; }
+</style>
+</head>
+<body>
+<h1>{{.ROM}}</h1>
+<p>Instructions: {{.Hit}}/{{.Instructions}} ({{printf "%.1f" .InstructionPercent}}%)<br>
+Branches: {{.DirectionsHit}}/{{.Directions}} ({{printf "%.1f" .DirectionPercent}}%)</p>
+<table>
+<tr><th>Address</th><th>Opcode</th><th>Instruction</th><th>Count</th><th>Branches</th></tr>
+{{range .Lines}}<tr class="{{.Status}}"><td>{{printf "0x%03x" .Address}}</td><td>{{printf "%04X" .Opcode}}</td><td>{{.Mnemonic}}</td><td>{{.Count}}</td><td>{{if .Skip}}taken {{.Branch.Taken}}, not taken {{.Branch.NotTaken}}{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func runCoverage(args []string) {
+	flags := flag.NewFlagSet("coverage", flag.ExitOnError)
+	romName := flags.String("rom", "", "Rom the coverage was recorded for, the rom stored in the coverage if not set")
+	format := flags.String("format", "text", "Output format: text or html")
+	output := flags.String("output", "", "Output file, stdout if not set")
+	loadAddress := loadAddressFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: miya coverage report coverage.json [--rom rom.ch8] [--format text|html] [--output file] [--platform name] [--load-address 0x200]\n")
+		flags.PrintDefaults()
+	}
+
+	// NOTE: The coverage file comes first, so the flags after it are parsed too
+	if len(args) < 2 || args[0] != "report" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	flags.Parse(args[2:])
+
+	c, err := coverage.Load(args[1])
+	if err != nil {
+		log.Fatalf("coverage.Load(): %v\n", err)
+	}
+
+	if *romName == "" {
+		*romName = c.ROM()
+	}
+
+	buffer, err := os.ReadFile(*romName)
+	if err != nil {
+		log.Fatalf("os.ReadFile(): %v\n", err)
+	}
+
+	if rom.SHA1(buffer) != c.SHA1() {
+		log.Printf("%s has changed since the coverage was recorded\n", *romName)
+	}
+
+	report := newCoverageReport(*romName, buffer, loadAddress(c.LoadAddress()), c)
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("os.Create(): %v\n", err)
+		}
+		defer file.Close()
+
+		w = file
+	}
+
+	switch *format {
+	case "text":
+		err = report.writeText(w)
+	case "html":
+		err = coverageTemplate.Execute(w, report)
+	default:
+		log.Fatalf("unknown format: %q, want text or html\n", *format)
+	}
+
+	if err != nil {
+		log.Fatalf("report.Write(): %v\n", err)
+	}
+}
+
+// NOTE: The instructions are the ones found by following the control flow and the ones that were executed,
+// BNNN targets and code reached through stores are only known from the run
+func newCoverageReport(fname string, buffer []byte, load uint16, c *coverage.Coverage) *coverageReport {
+	report := coverageReport{ROM: fname}
+	program := vm.Disassemble(buffer, load)
+
+	seen := make(map[uint16]bool)
+	addresses := program.Addresses()
+	for _, address := range addresses {
+		seen[address] = true
+	}
+
+	for _, address := range c.Addresses() {
+		if !seen[address] && address >= load && int(address-load)+2 <= len(buffer) {
+			addresses = append(addresses, address)
+		}
+	}
+
+	sort.Slice(addresses, func(i, k int) bool {
+		return addresses[i] < addresses[k]
+	})
+
+	for _, address := range addresses {
+		offset := address - load
+		line := coverageLine{
+			Address: address,
+			Opcode:  uint16(buffer[offset])<<8 | uint16(buffer[offset+1]),
+			Count:   c.Count(address),
+			Branch:  c.Branch(address),
+		}
+
+		line.Mnemonic = vm.Mnemonic(line.Opcode)
+
+		for _, edge := range program.Edges(address) {
+			line.Skip = line.Skip || edge.Kind == vm.EDGE_SKIP
+		}
+
+		line.Status = COVERAGE_MISS
+		if line.Count > 0 {
+			line.Status = COVERAGE_HIT
+			report.Hit++
+		}
+
+		if line.Skip {
+			report.Directions += 2
+
+			if line.Branch.Taken > 0 {
+				report.DirectionsHit++
+			}
+
+			if line.Branch.NotTaken > 0 {
+				report.DirectionsHit++
+			}
+
+			if line.Count > 0 && (line.Branch.Taken == 0 || line.Branch.NotTaken == 0) {
+				line.Status = COVERAGE_PARTIAL
+			}
+		}
+
+		report.Instructions++
+		report.Lines = append(report.Lines, line)
+	}
+
+	return &report
+}
+
+func (report *coverageReport) InstructionPercent() float64 {
+	return coveragePercent(report.Hit, report.Instructions)
+}
+
+func (report *coverageReport) DirectionPercent() float64 {
+	return coveragePercent(report.DirectionsHit, report.Directions)
+}
+
+func (report *coverageReport) writeText(w io.Writer) error {
+	var b strings.Builder
+
+	for _, line := range report.Lines {
+		fmt.Fprintf(&b, "%-7s 0x%03x  %04X  %-20s %8d", line.Status, line.Address, line.Opcode, line.Mnemonic, line.Count)
+
+		if line.Skip {
+			fmt.Fprintf(&b, "  taken %d, not taken %d", line.Branch.Taken, line.Branch.NotTaken)
+		}
+
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "Instructions: %d/%d (%.1f%%)\n", report.Hit, report.Instructions, report.InstructionPercent())
+	fmt.Fprintf(&b, "Branches:     %d/%d (%.1f%%)\n", report.DirectionsHit, report.Directions, report.DirectionPercent())
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func coveragePercent(count, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+
+	return 100 * float64(count) / float64(total)
+}
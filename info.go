@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"miya/internal/rom"
+	"miya/internal/vm"
+	"os"
+	"strings"
+)
+
+func runInfo(args []string) {
+	flags := flag.NewFlagSet("info", flag.ExitOnError)
+	loadAddress := loadAddressFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: miya info rom.ch8 [--platform name] [--load-address 0x200]\n")
+		flags.PrintDefaults()
+	}
+
+	// NOTE: The rom comes first, so the flags after it are parsed too
+	if len(args) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	fname := args[0]
+	flags.Parse(args[1:])
+
+	buffer, err := os.ReadFile(fname)
+	if err != nil {
+		log.Fatalf("os.ReadFile(): %v\n", err)
+	}
+
+	printInfo(os.Stdout, fname, buffer, vm.Analyze(buffer, loadAddress(vm.ROM_ADDRESS)))
+}
+
+func printInfo(w io.Writer, fname string, buffer []byte, analysis *vm.Analysis) {
+	fmt.Fprintf(w, "File:       %s\n", fname)
+	fmt.Fprintf(w, "Size:       %d bytes\n", analysis.Size)
+	fmt.Fprintf(w, "SHA-1:      %s\n", rom.SHA1(buffer))
+	fmt.Fprintf(w, "Platform:   %s\n", analysis.Platform)
+
+	fmt.Fprintf(w, "Code:       %s\n", regions(analysis.Code))
+	fmt.Fprintf(w, "Data:       %s\n", regions(analysis.Data))
+	fmt.Fprintf(w, "Unused:     %s\n", regions(analysis.Unused))
+
+	fmt.Fprintf(w, "Quirks:")
+	if len(analysis.Quirks) == 0 {
+		fmt.Fprintf(w, "     none\n")
+	} else {
+		fmt.Fprintf(w, "\n")
+	}
+
+	// NOTE: Every quirk is printed once with the number of instructions that need it
+	seen := make(map[string]int)
+	var order []vm.Quirk
+	for _, quirk := range analysis.Quirks {
+		if seen[quirk.Name] == 0 {
+			order = append(order, quirk)
+		}
+
+		seen[quirk.Name]++
+	}
+
+	for _, quirk := range order {
+		fmt.Fprintf(w, "  %-9s %s, %d instructions, first at 0x%03x\n", quirk.Name, quirk.Reason, seen[quirk.Name], quirk.Address)
+	}
+
+	fmt.Fprintf(w, "Self-modifying code:")
+	if len(analysis.Writes) == 0 {
+		fmt.Fprintf(w, " none\n")
+	} else {
+		fmt.Fprintf(w, "\n")
+	}
+
+	for _, write := range analysis.Writes {
+		fmt.Fprintf(w, "  0x%03x writes code at 0x%03x\n", write.Address, write.Target)
+	}
+
+	unresolved := make([]string, len(analysis.Unresolved))
+	for i, address := range analysis.Unresolved {
+		unresolved[i] = fmt.Sprintf("0x%03x", address)
+	}
+
+	if len(unresolved) == 0 {
+		unresolved = append(unresolved, "none")
+	}
+
+	fmt.Fprintf(w, "Computed jumps: %s\n", strings.Join(unresolved, ", "))
+}
+
+func regions(regions []vm.Region) string {
+	if len(regions) == 0 {
+		return "none"
+	}
+
+	total := 0
+	parts := make([]string, len(regions))
+	for i, region := range regions {
+		parts[i] = fmt.Sprintf("0x%03x-0x%03x", region.Start, region.End)
+		total += region.Size()
+	}
+
+	return fmt.Sprintf("%d bytes, %s", total, strings.Join(parts, " "))
+}
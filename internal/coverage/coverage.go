@@ -0,0 +1,207 @@
+package coverage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+const COVERAGE_VERSION = 1
+
+// NOTE: Files written before the load address was stored are from roms loaded at 0x200
+const DEFAULT_LOAD_ADDRESS = 0x200
+
+// Coverage records how often every address was executed and, for skip instructions,
+// how often the skip was taken and not taken
+type Coverage struct {
+	lock     sync.Mutex
+	rom      string
+	sha1     string
+	load     uint16
+	counts   map[uint16]uint64
+	branches map[uint16]*Branch
+}
+
+type Branch struct {
+	Address  uint16 `json:"address"`
+	Taken    uint64 `json:"taken"`
+	NotTaken uint64 `json:"not_taken"`
+}
+
+type Hit struct {
+	Address uint16 `json:"address"`
+	Count   uint64 `json:"count"`
+}
+
+type file struct {
+	Version      int      `json:"version"`
+	ROM          string   `json:"rom"`
+	SHA1         string   `json:"sha1"`
+	LoadAddress  uint16   `json:"load_address"`
+	Instructions []Hit    `json:"instructions"`
+	Branches     []Branch `json:"branches"`
+}
+
+func New(rom, sha1 string, load uint16) *Coverage {
+	return &Coverage{
+		rom:      rom,
+		sha1:     sha1,
+		load:     load,
+		counts:   make(map[uint16]uint64),
+		branches: make(map[uint16]*Branch),
+	}
+}
+
+func (coverage *Coverage) Add(address uint16) {
+	coverage.lock.Lock()
+	defer coverage.lock.Unlock()
+
+	coverage.counts[address]++
+}
+
+func (coverage *Coverage) AddBranch(address uint16, taken bool) {
+	coverage.lock.Lock()
+	defer coverage.lock.Unlock()
+
+	branch, ok := coverage.branches[address]
+	if !ok {
+		branch = &Branch{Address: address}
+		coverage.branches[address] = branch
+	}
+
+	if taken {
+		branch.Taken++
+	} else {
+		branch.NotTaken++
+	}
+}
+
+func (coverage *Coverage) ROM() string {
+	return coverage.rom
+}
+
+func (coverage *Coverage) SHA1() string {
+	return coverage.sha1
+}
+
+func (coverage *Coverage) LoadAddress() uint16 {
+	return coverage.load
+}
+
+func (coverage *Coverage) Count(address uint16) uint64 {
+	coverage.lock.Lock()
+	defer coverage.lock.Unlock()
+
+	return coverage.counts[address]
+}
+
+func (coverage *Coverage) Branch(address uint16) Branch {
+	coverage.lock.Lock()
+	defer coverage.lock.Unlock()
+
+	if branch, ok := coverage.branches[address]; ok {
+		return *branch
+	}
+
+	return Branch{Address: address}
+}
+
+func (coverage *Coverage) Addresses() []uint16 {
+	coverage.lock.Lock()
+	defer coverage.lock.Unlock()
+
+	addresses := make([]uint16, 0, len(coverage.counts))
+	for address := range coverage.counts {
+		addresses = append(addresses, address)
+	}
+
+	sort.Slice(addresses, func(i, k int) bool {
+		return addresses[i] < addresses[k]
+	})
+
+	return addresses
+}
+
+func (coverage *Coverage) Write(w io.Writer) error {
+	f := file{
+		Version:      COVERAGE_VERSION,
+		ROM:          coverage.rom,
+		SHA1:         coverage.sha1,
+		LoadAddress:  coverage.load,
+		Instructions: []Hit{},
+		Branches:     []Branch{},
+	}
+
+	for _, address := range coverage.Addresses() {
+		f.Instructions = append(f.Instructions, Hit{address, coverage.Count(address)})
+	}
+
+	coverage.lock.Lock()
+	for _, branch := range coverage.branches {
+		f.Branches = append(f.Branches, *branch)
+	}
+	coverage.lock.Unlock()
+
+	sort.Slice(f.Branches, func(i, k int) bool {
+		return f.Branches[i].Address < f.Branches[k].Address
+	})
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(f)
+}
+
+func (coverage *Coverage) Save(fname string) error {
+	out, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+
+	if err := coverage.Write(out); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+func Load(fname string) (*Coverage, error) {
+	in, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	return Read(in)
+}
+
+func Read(r io.Reader) (*Coverage, error) {
+	var f file
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, err
+	}
+
+	if f.Version != COVERAGE_VERSION {
+		return nil, fmt.Errorf("unsupported coverage version %d, want %d", f.Version, COVERAGE_VERSION)
+	}
+
+	if f.LoadAddress == 0 {
+		f.LoadAddress = DEFAULT_LOAD_ADDRESS
+	}
+
+	coverage := New(f.ROM, f.SHA1, f.LoadAddress)
+	for _, hit := range f.Instructions {
+		coverage.counts[hit.Address] += hit.Count
+	}
+
+	for _, branch := range f.Branches {
+		b := branch
+		coverage.branches[branch.Address] = &b
+	}
+
+	return coverage, nil
+}
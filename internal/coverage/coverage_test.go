@@ -0,0 +1,57 @@
+package coverage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	var buffer bytes.Buffer
+
+	coverage := New("pong.ch8", "abc", 0x600)
+	coverage.Add(0x202)
+	coverage.Add(0x200)
+	coverage.Add(0x202)
+	coverage.AddBranch(0x202, true)
+	coverage.AddBranch(0x202, false)
+	coverage.AddBranch(0x202, false)
+
+	if err := coverage.Write(&buffer); err != nil {
+		t.Fatalf("coverage.Write(): %v\n", err)
+	}
+
+	loaded, err := Read(&buffer)
+	if err != nil {
+		t.Fatalf("Read(): %v\n", err)
+	}
+
+	if loaded.ROM() != "pong.ch8" || loaded.SHA1() != "abc" || loaded.LoadAddress() != 0x600 {
+		t.Errorf("got rom: %q, sha1: %q, load address: 0x%03x, want rom: %q, sha1: %q, load address: 0x%03x\n", loaded.ROM(), loaded.SHA1(), loaded.LoadAddress(), "pong.ch8", "abc", 0x600)
+	}
+
+	if loaded.Count(0x200) != 1 || loaded.Count(0x202) != 2 || loaded.Count(0x204) != 0 {
+		t.Errorf("got counts: %d, %d, %d, want counts: 1, 2, 0\n", loaded.Count(0x200), loaded.Count(0x202), loaded.Count(0x204))
+	}
+
+	if branch := loaded.Branch(0x202); branch.Taken != 1 || branch.NotTaken != 2 {
+		t.Errorf("got branch: %+v, want taken 1, not taken 2\n", branch)
+	}
+}
+
+func TestRead_version(t *testing.T) {
+	if _, err := Read(strings.NewReader(`{"version": 2}`)); err == nil {
+		t.Errorf("got err: nil, want err for version 2\n")
+	}
+}
+
+func TestRead_loadAddress(t *testing.T) {
+	loaded, err := Read(strings.NewReader(`{"version": 1, "rom": "pong.ch8"}`))
+	if err != nil {
+		t.Fatalf("Read(): %v\n", err)
+	}
+
+	if loaded.LoadAddress() != DEFAULT_LOAD_ADDRESS {
+		t.Errorf("got load address: 0x%03x, want load address: 0x%03x\n", loaded.LoadAddress(), DEFAULT_LOAD_ADDRESS)
+	}
+}
@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"sync"
+)
+
+type Access byte
+
+const (
+	ACCESS_EXECUTE Access = 1 << iota
+	ACCESS_READ
+	ACCESS_WRITE
+	ACCESS_SMC
+)
+
+// NOTE: The heat of a byte is set on every access and fades over about half a second of frames
+const HEAT_MAX = 0xFF
+const HEAT_DECAY = 0x08
+
+// AccessMap tags every byte with the ways the running program has used it: executed, read as data
+// through I, written, or executed after it was written. Bytes loaded with the rom are not writes
+type AccessMap struct {
+	lock    sync.Mutex
+	access  []Access
+	heat    []byte
+	written []bool
+}
+
+type Heatmap struct {
+	Access []Access
+	Heat   []byte
+}
+
+func NewAccessMap(size int) *AccessMap {
+	return &AccessMap{
+		access:  make([]Access, size),
+		heat:    make([]byte, size),
+		written: make([]bool, size),
+	}
+}
+
+// NOTE: Returns true the first time a byte is executed after every write to it, so self-modifying code is reported once per change
+func (m *AccessMap) Execute(addr uint16) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	modified := false
+	for _, a := range []uint16{addr, addr + 1} {
+		if !m.contains(a) {
+			continue
+		}
+
+		m.touch(a, ACCESS_EXECUTE)
+
+		if m.written[a] {
+			m.access[a] |= ACCESS_SMC
+			m.written[a] = false
+			modified = true
+		}
+	}
+
+	return modified
+}
+
+func (m *AccessMap) Read(addr uint16) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.contains(addr) {
+		m.touch(addr, ACCESS_READ)
+	}
+}
+
+func (m *AccessMap) Write(addr uint16) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.contains(addr) {
+		m.touch(addr, ACCESS_WRITE)
+		m.written[addr] = true
+	}
+}
+
+func (m *AccessMap) Access(addr uint16) Access {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.contains(addr) {
+		return 0
+	}
+
+	return m.access[addr]
+}
+
+func (m *AccessMap) Decay() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for i, heat := range m.heat {
+		if heat > HEAT_DECAY {
+			m.heat[i] = heat - HEAT_DECAY
+		} else {
+			m.heat[i] = 0
+		}
+	}
+}
+
+func (m *AccessMap) Heatmap() Heatmap {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return Heatmap{
+		Access: append([]Access(nil), m.access...),
+		Heat:   append([]byte(nil), m.heat...),
+	}
+}
+
+func (m *AccessMap) Reset() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for i := range m.access {
+		m.access[i] = 0
+		m.heat[i] = 0
+		m.written[i] = false
+	}
+}
+
+func (m *AccessMap) contains(addr uint16) bool {
+	return int(addr) < len(m.access)
+}
+
+func (m *AccessMap) touch(addr uint16, access Access) {
+	m.access[addr] |= access
+	m.heat[addr] = HEAT_MAX
+}
@@ -0,0 +1,40 @@
+package memory
+
+import "testing"
+
+func TestAccessMap(t *testing.T) {
+	m := NewAccessMap(CHIP8_MEMORY_SIZE)
+
+	if m.Execute(0x200) {
+		t.Errorf("got modified: true, want modified: false for unwritten bytes\n")
+	}
+
+	m.Read(0x300)
+	m.Write(0x201)
+
+	if !m.Execute(0x200) {
+		t.Errorf("got modified: false, want modified: true after a write\n")
+	}
+
+	if m.Execute(0x200) {
+		t.Errorf("got modified: true, want modified: false for the second execution\n")
+	}
+
+	if access := m.Access(0x201); access != ACCESS_EXECUTE|ACCESS_WRITE|ACCESS_SMC {
+		t.Errorf("got access: %04b, want access: %04b\n", access, ACCESS_EXECUTE|ACCESS_WRITE|ACCESS_SMC)
+	}
+
+	if access := m.Access(0x300); access != ACCESS_READ {
+		t.Errorf("got access: %04b, want access: %04b\n", access, ACCESS_READ)
+	}
+
+	m.Decay()
+	if heat := m.Heatmap().Heat[0x300]; heat != HEAT_MAX-HEAT_DECAY {
+		t.Errorf("got heat: %d, want heat: %d\n", heat, HEAT_MAX-HEAT_DECAY)
+	}
+
+	m.Reset()
+	if access := m.Access(0x200); access != 0 {
+		t.Errorf("got access: %04b, want access: %04b after reset\n", access, 0)
+	}
+}
@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	WATCH_READ = 1 << iota
+	WATCH_WRITE
+)
+
+// Bus is the memory the virtualmachine runs on, Memory is the plain implementation and the
+// decorators below wrap any Bus to trace or watch the accesses
+type Bus interface {
+	Read(addr uint16) byte
+	Write(addr uint16, data byte)
+	ReadOpcode(addr uint16) uint16
+	WriteArray(addr uint16, data []byte) error
+	Reset()
+	Dump() []byte
+	Size() int
+}
+
+// CallStack holds a frame for every CALL that has not returned yet, Stack is the plain implementation.
+// Push fails when the stack is full and Pop when it is empty
+type CallStack interface {
+	Push(frame Frame) error
+	Pop() (Frame, error)
+	Reset()
+	Frames() []Frame
+	Depth() int
+}
+
+// TraceBus writes a line for every read, write and opcode fetch
+type TraceBus struct {
+	Bus
+	w io.Writer
+}
+
+type WatchHit struct {
+	Kind    int
+	Address uint16
+	Data    byte
+}
+
+// WatchBus calls hit for every read or write of a watched address
+type WatchBus struct {
+	Bus
+	watch map[uint16]int
+	hit   func(WatchHit)
+}
+
+// TraceStack writes a line for every push and pop
+type TraceStack struct {
+	CallStack
+	w io.Writer
+}
+
+func NewTraceBus(bus Bus, w io.Writer) *TraceBus {
+	return &TraceBus{bus, w}
+}
+
+func (bus *TraceBus) Read(addr uint16) byte {
+	data := bus.Bus.Read(addr)
+	fmt.Fprintf(bus.w, "read  0x%03x: 0x%02x\n", addr, data)
+
+	return data
+}
+
+func (bus *TraceBus) Write(addr uint16, data byte) {
+	fmt.Fprintf(bus.w, "write 0x%03x: 0x%02x\n", addr, data)
+	bus.Bus.Write(addr, data)
+}
+
+func (bus *TraceBus) ReadOpcode(addr uint16) uint16 {
+	opcode := bus.Bus.ReadOpcode(addr)
+	fmt.Fprintf(bus.w, "fetch 0x%03x: 0x%04x\n", addr, opcode)
+
+	return opcode
+}
+
+// NOTE: Loading a rom or a state is not traced byte by byte
+func (bus *TraceBus) WriteArray(addr uint16, data []byte) error {
+	fmt.Fprintf(bus.w, "load  0x%03x: %d bytes\n", addr, len(data))
+	return bus.Bus.WriteArray(addr, data)
+}
+
+func NewWatchBus(bus Bus, hit func(WatchHit)) *WatchBus {
+	return &WatchBus{bus, make(map[uint16]int), hit}
+}
+
+func (bus *WatchBus) Watch(addr uint16, kind int) {
+	bus.watch[addr] |= kind
+}
+
+func (bus *WatchBus) Unwatch(addr uint16) {
+	delete(bus.watch, addr)
+}
+
+func (bus *WatchBus) Read(addr uint16) byte {
+	data := bus.Bus.Read(addr)
+	if bus.watch[addr]&WATCH_READ != 0 {
+		bus.hit(WatchHit{WATCH_READ, addr, data})
+	}
+
+	return data
+}
+
+func (bus *WatchBus) Write(addr uint16, data byte) {
+	bus.Bus.Write(addr, data)
+	if bus.watch[addr]&WATCH_WRITE != 0 {
+		bus.hit(WatchHit{WATCH_WRITE, addr, data})
+	}
+}
+
+// NOTE: Opcode fetches are not data reads, a watchpoint on code only fires when the code is read through I
+func (bus *WatchBus) ReadOpcode(addr uint16) uint16 {
+	return bus.Bus.ReadOpcode(addr)
+}
+
+func (bus *WatchBus) WriteArray(addr uint16, data []byte) error {
+	if err := fits(addr, len(data), bus.Size()); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(data); i++ {
+		bus.Write(addr+uint16(i), data[i])
+	}
+
+	return nil
+}
+
+func NewTraceStack(stack CallStack, w io.Writer) *TraceStack {
+	return &TraceStack{stack, w}
+}
+
+func (stack *TraceStack) Push(frame Frame) error {
+	err := stack.CallStack.Push(frame)
+	fmt.Fprintf(stack.w, "push  0x%03x -> 0x%03x, depth %d\n", frame.Caller, frame.Callee, stack.Depth())
+
+	return err
+}
+
+func (stack *TraceStack) Pop() (Frame, error) {
+	frame, err := stack.CallStack.Pop()
+	fmt.Fprintf(stack.w, "pop   0x%03x <- 0x%03x, depth %d\n", frame.Caller, frame.Callee, stack.Depth())
+
+	return frame, err
+}
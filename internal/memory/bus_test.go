@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTraceBus(t *testing.T) {
+	var buffer bytes.Buffer
+
+	bus := NewTraceBus(NewMemory(CHIP8_MEMORY_SIZE), &buffer)
+	bus.Write(0x300, 0xAB)
+	bus.Read(0x300)
+	bus.ReadOpcode(0x300)
+
+	if want := "write 0x300: 0xab\nread  0x300: 0xab\nfetch 0x300: 0xab00\n"; buffer.String() != want {
+		t.Errorf("got trace: %q, want trace: %q\n", buffer.String(), want)
+	}
+}
+
+func TestWatchBus(t *testing.T) {
+	var hits []WatchHit
+
+	bus := NewWatchBus(NewMemory(CHIP8_MEMORY_SIZE), func(hit WatchHit) {
+		hits = append(hits, hit)
+	})
+
+	bus.Watch(0x300, WATCH_WRITE)
+	bus.WriteArray(0x2FF, []byte{0x01, 0x02})
+	bus.Read(0x300)
+	bus.Unwatch(0x300)
+	bus.Write(0x300, 0x03)
+
+	if len(hits) != 1 || hits[0] != (WatchHit{WATCH_WRITE, 0x300, 0x02}) {
+		t.Errorf("got hits: %v, want hits: %v\n", hits, []WatchHit{{WATCH_WRITE, 0x300, 0x02}})
+	}
+}
+
+func TestTraceStack(t *testing.T) {
+	var buffer bytes.Buffer
+
+	stack := NewTraceStack(NewStack(CHIP8_STACK_SIZE), &buffer)
+	stack.Push(Frame{Caller: 0x202, Callee: 0x300})
+	stack.Pop()
+
+	if want := "push  0x202 -> 0x300, depth 1\npop   0x202 <- 0x300, depth 0\n"; buffer.String() != want {
+		t.Errorf("got trace: %q, want trace: %q\n", buffer.String(), want)
+	}
+}
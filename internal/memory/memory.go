@@ -1,6 +1,11 @@
 package memory
 
-const CHIP8_MEMORY_SIZE = 0xFFF
+import (
+	"fmt"
+)
+
+const CHIP8_MEMORY_SIZE = 0x1000
+const MAX_MEMORY_SIZE = 0x10000
 
 type Memory struct {
 	buffer []byte
@@ -13,13 +18,13 @@ func NewMemory(size int) *Memory {
 }
 
 func (memory *Memory) Write(addr uint16, data byte) {
-	if addr < CHIP8_MEMORY_SIZE {
+	if int(addr) < len(memory.buffer) {
 		memory.buffer[addr] = data
 	}
 }
 
 func (memory Memory) Read(addr uint16) byte {
-	if addr < CHIP8_MEMORY_SIZE {
+	if int(addr) < len(memory.buffer) {
 		return memory.buffer[addr]
 	}
 
@@ -27,7 +32,7 @@ func (memory Memory) Read(addr uint16) byte {
 }
 
 func (memory *Memory) Reset() {
-	for i := 0; i < CHIP8_MEMORY_SIZE; i++ {
+	for i := range memory.buffer {
 		memory.buffer[i] = 0x00
 	}
 }
@@ -41,8 +46,29 @@ func (memory Memory) ReadOpcode(addr uint16) uint16 {
 	return opcode
 }
 
-func (memory *Memory) WriteArray(addr uint16, data []byte) {
-	for i := 0; i < len(data); i++ {
-		memory.Write(addr+uint16(i), data[i])
+// NOTE: Nothing is written when the data does not fit, a rom cut short would run into zeros
+func (memory *Memory) WriteArray(addr uint16, data []byte) error {
+	if err := fits(addr, len(data), len(memory.buffer)); err != nil {
+		return err
 	}
+
+	copy(memory.buffer[addr:], data)
+
+	return nil
+}
+
+func (memory Memory) Dump() []byte {
+	return append([]byte(nil), memory.buffer...)
+}
+
+func (memory Memory) Size() int {
+	return len(memory.buffer)
+}
+
+func fits(addr uint16, length, size int) error {
+	if int(addr)+length > size {
+		return fmt.Errorf("%d bytes at 0x%03x do not fit in %d bytes of memory, %d bytes too many", length, addr, size, int(addr)+length-size)
+	}
+
+	return nil
 }
@@ -82,3 +82,33 @@ func TestMemoryWriteArray(t *testing.T) {
 		}
 	}
 }
+
+func TestMemoryWriteArray_oobs(t *testing.T) {
+	if err := memtest.WriteArray(CHIP8_MEMORY_SIZE-2, []byte{0x01, 0x02, 0x03}); err == nil {
+		t.Errorf("got err: nil, want err for 3 bytes at 0x%04x\n", CHIP8_MEMORY_SIZE-2)
+	}
+
+	if memtest.Read(CHIP8_MEMORY_SIZE-2) != 0x00 {
+		t.Errorf("got memory[0x%04x]: 0x%02x, want memory[0x%04x]: 0x%02x\n", CHIP8_MEMORY_SIZE-2, memtest.Read(CHIP8_MEMORY_SIZE-2), CHIP8_MEMORY_SIZE-2, 0x00)
+	}
+
+	memtest.Write(CHIP8_MEMORY_SIZE-1, 0xAB)
+	if memtest.Read(CHIP8_MEMORY_SIZE-1) != 0xAB {
+		t.Errorf("got memory[0x%04x]: 0x%02x, want memory[0x%04x]: 0x%02x\n", CHIP8_MEMORY_SIZE-1, memtest.Read(CHIP8_MEMORY_SIZE-1), CHIP8_MEMORY_SIZE-1, 0xAB)
+	}
+
+	memtest.Reset()
+}
+
+func TestMemoryDump(t *testing.T) {
+	memtest.Write(0x200, 0xAB)
+
+	dump := memtest.Dump()
+	memtest.Write(0x200, 0x00)
+
+	if dump[0x200] != 0xAB {
+		t.Errorf("got dump[0x200]: 0x%02x, want dump[0x200]: 0x%02x\n", dump[0x200], 0xAB)
+	}
+
+	memtest.Reset()
+}
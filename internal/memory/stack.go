@@ -1,46 +1,76 @@
 package memory
 
+import (
+	"fmt"
+)
+
 const CHIP8_STACK_SIZE = 0x10
 
+// NOTE: An unlimited stack grows with every call, for debugging roms that recurse deeper than the hardware allows
+const STACK_UNLIMITED = -1
+
+// Frame is one call on the stack: where the CALL was, where it went, the cycle it happened at
+// and the registers at that moment. RET returns to the instruction after Caller
+type Frame struct {
+	Caller uint16
+	Callee uint16
+	Cycle  uint64
+	V      [0x10]byte
+	I      uint16
+}
+
 type Stack struct {
-	buffer []uint16
-	sp     uint8
+	frames []Frame
+	size   int
 }
 
 func NewStack(size int) *Stack {
+	capacity := size
+	if size == STACK_UNLIMITED {
+		capacity = CHIP8_STACK_SIZE
+	}
+
 	return &Stack{
-		make([]uint16, size),
-		0x00,
+		make([]Frame, 0, capacity),
+		size,
 	}
 }
 
 func (stack *Stack) Reset() {
-	for i := 0; i < CHIP8_STACK_SIZE; i++ {
-		stack.buffer[i] = 0x00
+	stack.frames = stack.frames[:0]
+}
+
+func (stack *Stack) Push(frame Frame) error {
+	if stack.size != STACK_UNLIMITED && len(stack.frames) >= stack.size {
+		return fmt.Errorf("stack overflow, %d frames deep", len(stack.frames))
 	}
 
-	stack.sp = 0
+	stack.frames = append(stack.frames, frame)
+
+	return nil
 }
 
-func (stack *Stack) Push(data uint16) {
-	if stack.sp < CHIP8_STACK_SIZE {
-		stack.buffer[stack.sp] = data
-		stack.sp++
+// NOTE: Popping an empty stack returns a zero frame, so the RET goes to 0x002 like it used to
+func (stack *Stack) Pop() (Frame, error) {
+	if len(stack.frames) == 0 {
+		return Frame{}, fmt.Errorf("stack underflow")
 	}
-}
 
-func (stack *Stack) Pop() uint16 {
-	if stack.sp > 0 {
-		data := stack.buffer[stack.sp-1]
-		stack.buffer[stack.sp-1] = 0x00
-		stack.sp--
+	frame := stack.frames[len(stack.frames)-1]
+	stack.frames = stack.frames[:len(stack.frames)-1]
 
-		return data
-	}
+	return frame, nil
+}
+
+// Frames are the live frames, outermost first
+func (stack *Stack) Frames() []Frame {
+	return append([]Frame(nil), stack.frames...)
+}
 
-	return stack.buffer[stack.sp]
+func (stack *Stack) Depth() int {
+	return len(stack.frames)
 }
 
-func (stack *Stack) Dump() []uint16 {
-	return stack.buffer
+func (stack *Stack) Size() int {
+	return stack.size
 }
@@ -7,75 +7,115 @@ func init() {
 }
 
 func TestStackReset(t *testing.T) {
-	for i := 0; i < CHIP8_STACK_SIZE; i++ {
-		stacktest.buffer[i] = 0xFF
-	}
+	stacktest.Push(Frame{Caller: 0x200})
+	stacktest.Push(Frame{Caller: 0x300})
 
 	stacktest.Reset()
 
-	for i := 0; i < CHIP8_STACK_SIZE; i++ {
-		if stacktest.buffer[i] != 0x00 {
-			t.Errorf("got stack[0x%02x]: 0x%02x, want stack[0x%02x]: 0x%02x\n", i, stacktest.buffer[i], i, 0x00)
-		}
+	if stacktest.Depth() != 0 {
+		t.Errorf("got depth: %d, want depth: %d", stacktest.Depth(), 0)
 	}
 
-	if stacktest.sp != 0 {
-		t.Errorf("got SP: %d, want SP: %d", stacktest.sp, 0)
+	if frames := stacktest.Frames(); len(frames) != 0 {
+		t.Errorf("got frames: %v, want frames: []\n", frames)
 	}
 }
 
 func TestStackPush(t *testing.T) {
-	stacktest.Push(0xFF)
-	stacktest.Push(0xAB)
+	stacktest.Push(Frame{Caller: 0xFF})
+	stacktest.Push(Frame{Caller: 0xAB, Callee: 0x300, Cycle: 7, I: 0x123})
+
+	frames := stacktest.Frames()
+	if len(frames) != 2 {
+		t.Fatalf("got %d frames, want %d frames", len(frames), 2)
+	}
+
+	if frames[0].Caller != 0xFF {
+		t.Errorf("got frames[0].Caller: 0x%02x, want frames[0].Caller: 0x%02x\n", frames[0].Caller, 0xFF)
+	}
+
+	if want := (Frame{Caller: 0xAB, Callee: 0x300, Cycle: 7, I: 0x123}); frames[1] != want {
+		t.Errorf("got frames[1]: %+v, want frames[1]: %+v\n", frames[1], want)
+	}
 
-	if stacktest.sp != 2 {
-		t.Errorf("got SP: %d, want SP: %d", stacktest.sp, 2)
+	stacktest.Reset()
+}
+
+func TestStackPush_overflow(t *testing.T) {
+	for i := 0; i < CHIP8_STACK_SIZE; i++ {
+		if err := stacktest.Push(Frame{Caller: uint16(i)}); err != nil {
+			t.Errorf("got stack.Push() #%d: %v, want stack.Push(): nil\n", i, err)
+		}
 	}
 
-	if stacktest.buffer[stacktest.sp-1] != 0xAB {
-		t.Errorf("got stack[0x%02x]: 0x%02x, want stack[0x%02x]: 0x%02x\n", stacktest.sp-1, stacktest.buffer[stacktest.sp-1], stacktest.sp-1, 0xAB)
+	if err := stacktest.Push(Frame{Caller: 0xFF}); err == nil {
+		t.Errorf("got stack.Push(): nil, want stack.Push(): stack overflow\n")
 	}
 
-	if stacktest.buffer[stacktest.sp-2] != 0xFF {
-		t.Errorf("got stack[0x%02x]: 0x%02x, want stack[0x%02x]: 0x%02x\n", stacktest.sp-2, stacktest.buffer[stacktest.sp-2], stacktest.sp-2, 0xFF)
+	if stacktest.Depth() != CHIP8_STACK_SIZE {
+		t.Errorf("got depth: %d, want depth: %d", stacktest.Depth(), CHIP8_STACK_SIZE)
 	}
 
 	stacktest.Reset()
 }
 
+func TestStackPush_unlimited(t *testing.T) {
+	stack := NewStack(STACK_UNLIMITED)
+
+	for i := 0; i < 0x100; i++ {
+		if err := stack.Push(Frame{Caller: uint16(i)}); err != nil {
+			t.Fatalf("got stack.Push() #%d: %v, want stack.Push(): nil\n", i, err)
+		}
+	}
+
+	if stack.Depth() != 0x100 {
+		t.Errorf("got depth: %d, want depth: %d", stack.Depth(), 0x100)
+	}
+}
+
 func TestStackPop(t *testing.T) {
-	stacktest.Push(0xFF)
-	stacktest.Push(0xAB)
+	stacktest.Push(Frame{Caller: 0xFF})
+	stacktest.Push(Frame{Caller: 0xAB})
 
-	a := stacktest.Pop()
-	b := stacktest.Pop()
+	a, _ := stacktest.Pop()
+	b, _ := stacktest.Pop()
 
-	if stacktest.sp != 0 {
-		t.Errorf("got SP: %d, want SP: %d", stacktest.sp, 0)
+	if stacktest.Depth() != 0 {
+		t.Errorf("got depth: %d, want depth: %d", stacktest.Depth(), 0)
 	}
 
-	if a != 0xAB {
-		t.Errorf("got stack.pop(): 0x%02x, want stack.pop(): 0x%02x\n", a, 0xAB)
+	if a.Caller != 0xAB {
+		t.Errorf("got stack.pop(): 0x%02x, want stack.pop(): 0x%02x\n", a.Caller, 0xAB)
 	}
 
-	if b != 0xFF {
-		t.Errorf("got stack.pop(): 0x%02x, want stack.pop(): 0x%02x\n", b, 0xFF)
+	if b.Caller != 0xFF {
+		t.Errorf("got stack.pop(): 0x%02x, want stack.pop(): 0x%02x\n", b.Caller, 0xFF)
 	}
 }
 
 func TestStackPop_zsp(t *testing.T) {
-	a := stacktest.Pop()
-	b := stacktest.Pop()
+	a, err := stacktest.Pop()
 
-	if stacktest.sp != 0 {
-		t.Errorf("got SP: %d, want SP: %d", stacktest.sp, 0)
+	if stacktest.Depth() != 0 {
+		t.Errorf("got depth: %d, want depth: %d", stacktest.Depth(), 0)
 	}
 
-	if a != 0x00 {
-		t.Errorf("got stack.pop(): 0x%02x, want stack.pop(): 0x%02x\n", a, 0x00)
+	if a != (Frame{}) {
+		t.Errorf("got stack.pop(): %+v, want stack.pop(): %+v\n", a, Frame{})
 	}
 
-	if b != 0x00 {
-		t.Errorf("got stack.pop(): 0x%02x, want stack.pop(): 0x%02x\n", b, 0x00)
+	if err == nil {
+		t.Errorf("got stack.Pop(): nil, want stack.Pop(): stack underflow\n")
 	}
 }
+
+func TestStackDepth(t *testing.T) {
+	stacktest.Push(Frame{Caller: 0xFF})
+	stacktest.Push(Frame{Caller: 0xAB})
+
+	if stacktest.Depth() != 2 {
+		t.Errorf("got depth: %d, want depth: %d", stacktest.Depth(), 2)
+	}
+
+	stacktest.Reset()
+}
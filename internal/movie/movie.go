@@ -0,0 +1,170 @@
+package movie
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const MOVIE_HEADER = "miya-movie 1"
+
+type Input struct {
+	Frame   uint64
+	Key     byte
+	Pressed bool
+}
+
+// NOTE: ROM is the sha1 of the rom the movie was recorded on, movies from before it was stored have none
+type Movie struct {
+	ROM         string
+	Seed        int64
+	IPF         uint64
+	RNG         string
+	Timing      string
+	DisplayWait bool
+	Wrap        bool
+	Platform    Platform
+	Inputs      []Input
+}
+
+// Platform is the memory layout the movie was recorded on, movies from before it was stored have no name
+type Platform struct {
+	Name             string
+	MemorySize       int
+	LoadAddress      uint16
+	FontAddress      uint16
+	LargeFontAddress uint16
+	StackDepth       int
+}
+
+type Writer struct {
+	file *os.File
+}
+
+// NOTE: The header is everything in movie but the inputs, they are written as they happen
+func Create(fname string, movie Movie) (*Writer, error) {
+	file, err := os.Create(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := fmt.Fprintf(file, "%s\nseed %d\nipf %d\nrng %s\ntiming %s\ndisplay-wait %d\nwrap %d\n", MOVIE_HEADER, movie.Seed, movie.IPF, movie.RNG, movie.Timing, state(movie.DisplayWait), state(movie.Wrap)); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if _, err := fmt.Fprintf(file, "rom %s\n", movie.ROM); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	p := movie.Platform
+	if _, err := fmt.Fprintf(file, "platform %s memory-size 0x%x load-address 0x%03x font-address 0x%03x large-font-address 0x%03x stack-depth %d\n", p.Name, p.MemorySize, p.LoadAddress, p.FontAddress, p.LargeFontAddress, p.StackDepth); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &Writer{file}, nil
+}
+
+// NOTE: Every input is written straight to the file, so the movie survives os.Exit and crashes
+func (w *Writer) WriteInput(input Input) error {
+	_, err := fmt.Fprintf(w.file, "input %d %x %d\n", input.Frame, input.Key, state(input.Pressed))
+	return err
+}
+
+func (w *Writer) Close() error {
+	return w.file.Close()
+}
+
+func Load(fname string) (*Movie, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Read(file)
+}
+
+func Read(r io.Reader) (*Movie, error) {
+	var movie Movie
+
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || scanner.Text() != MOVIE_HEADER {
+		return nil, fmt.Errorf("not a movie file, want header %q", MOVIE_HEADER)
+	}
+
+	for line := 2; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+
+		switch fields[0] {
+		case "rom":
+			_, err = fmt.Sscanf(scanner.Text(), "rom %s", &movie.ROM)
+		case "seed":
+			_, err = fmt.Sscanf(scanner.Text(), "seed %d", &movie.Seed)
+		case "ipf":
+			_, err = fmt.Sscanf(scanner.Text(), "ipf %d", &movie.IPF)
+		case "rng":
+			_, err = fmt.Sscanf(scanner.Text(), "rng %s", &movie.RNG)
+		case "timing":
+			_, err = fmt.Sscanf(scanner.Text(), "timing %s", &movie.Timing)
+		case "display-wait":
+			var wait int
+
+			_, err = fmt.Sscanf(scanner.Text(), "display-wait %d", &wait)
+			movie.DisplayWait = wait == 1
+		case "wrap":
+			var wrap int
+
+			_, err = fmt.Sscanf(scanner.Text(), "wrap %d", &wrap)
+			movie.Wrap = wrap == 1
+		case "platform":
+			p := &movie.Platform
+			_, err = fmt.Sscanf(scanner.Text(), "platform %s memory-size %v load-address %v font-address %v large-font-address %v stack-depth %d", &p.Name, &p.MemorySize, &p.LoadAddress, &p.FontAddress, &p.LargeFontAddress, &p.StackDepth)
+		case "input":
+			var input Input
+			var pressed int
+
+			_, err = fmt.Sscanf(scanner.Text(), "input %d %x %d", &input.Frame, &input.Key, &pressed)
+			input.Pressed = pressed == 1
+
+			if err == nil && input.Key > 0x0F {
+				err = fmt.Errorf("invalid key 0x%02x", input.Key)
+			}
+
+			if err == nil && len(movie.Inputs) > 0 && movie.Inputs[len(movie.Inputs)-1].Frame > input.Frame {
+				err = fmt.Errorf("frame %d is out of order", input.Frame)
+			}
+
+			movie.Inputs = append(movie.Inputs, input)
+		default:
+			err = fmt.Errorf("unknown entry %q", fields[0])
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+
+	if movie.IPF == 0 {
+		return nil, fmt.Errorf("missing ipf")
+	}
+
+	return &movie, scanner.Err()
+}
+
+func state(pressed bool) int {
+	if pressed {
+		return 1
+	}
+
+	return 0
+}
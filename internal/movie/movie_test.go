@@ -0,0 +1,73 @@
+package movie
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateLoad(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "movie.txt")
+	inputs := []Input{
+		{Frame: 0, Key: 0x0A, Pressed: true},
+		{Frame: 12, Key: 0x0A, Pressed: false},
+	}
+
+	platform := Platform{"eti-660", 0x1000, 0x600, 0x000, 0x050, -1}
+
+	writer, err := Create(fname, Movie{Seed: -42, IPF: 15, RNG: "vip", Timing: "vip", DisplayWait: true, Wrap: true, Platform: platform, ROM: "da39a3ee5e6b4b0d3255bfef95601890afd80709"})
+	if err != nil {
+		t.Fatalf("Create(): %v\n", err)
+	}
+
+	for _, input := range inputs {
+		if err := writer.WriteInput(input); err != nil {
+			t.Fatalf("writer.WriteInput(): %v\n", err)
+		}
+	}
+
+	writer.Close()
+
+	movie, err := Load(fname)
+	if err != nil {
+		t.Fatalf("Load(): %v\n", err)
+	}
+
+	if movie.Seed != -42 || movie.IPF != 15 || movie.RNG != "vip" || movie.Timing != "vip" || !movie.DisplayWait || !movie.Wrap {
+		t.Errorf("got seed: %d, ipf: %d, rng: %s, timing: %s, display wait: %t, wrap: %t, want seed: %d, ipf: %d, rng: %s, timing: %s, display wait: %t, wrap: %t\n", movie.Seed, movie.IPF, movie.RNG, movie.Timing, movie.DisplayWait, movie.Wrap, -42, 15, "vip", "vip", true, true)
+	}
+
+	if movie.ROM != "da39a3ee5e6b4b0d3255bfef95601890afd80709" {
+		t.Errorf("got rom: %s, want rom: %s\n", movie.ROM, "da39a3ee5e6b4b0d3255bfef95601890afd80709")
+	}
+
+	if movie.Platform != platform {
+		t.Errorf("got platform: %+v, want platform: %+v\n", movie.Platform, platform)
+	}
+
+	if len(movie.Inputs) != len(inputs) {
+		t.Fatalf("got inputs: %d, want inputs: %d\n", len(movie.Inputs), len(inputs))
+	}
+
+	for i, input := range inputs {
+		if movie.Inputs[i] != input {
+			t.Errorf("got input[%d]: %v, want input[%d]: %v\n", i, movie.Inputs[i], i, input)
+		}
+	}
+}
+
+func TestRead_invalid(t *testing.T) {
+	movies := []string{
+		"",
+		"miya-movie 1\nseed 1\n",
+		"miya-movie 1\nseed 1\nipf 10\ninput 5 1 1\ninput 4 1 0\n",
+		"miya-movie 1\nseed 1\nipf 10\ninput 5 10 1\n",
+		"miya-movie 1\nspeed 1\n",
+	}
+
+	for _, m := range movies {
+		if _, err := Read(strings.NewReader(m)); err == nil {
+			t.Errorf("got err: nil, want err for %q\n", m)
+		}
+	}
+}
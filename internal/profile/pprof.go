@@ -0,0 +1,215 @@
+package profile
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// NOTE: Field numbers of profile.proto from github.com/google/pprof, the format go tool pprof reads
+const (
+	PPROF_SAMPLE_TYPE  = 1
+	PPROF_SAMPLE       = 2
+	PPROF_MAPPING      = 3
+	PPROF_LOCATION     = 4
+	PPROF_FUNCTION     = 5
+	PPROF_STRING_TABLE = 6
+	PPROF_PERIOD_TYPE  = 11
+	PPROF_PERIOD       = 12
+)
+
+type protobuf struct {
+	buffer []byte
+}
+
+type location struct {
+	function uint16
+	address  uint16
+}
+
+// WritePprof writes the samples as a gzipped pprof profile. Every subroutine is a function and every
+// address a line of it, the code outside of any subroutine belongs to main
+func (profiler *Profiler) WritePprof(w io.Writer) error {
+	profiler.lock.Lock()
+	defer profiler.lock.Unlock()
+
+	table := []string{""}
+	index := map[string]uint64{"": 0}
+	str := func(s string) uint64 {
+		if i, ok := index[s]; ok {
+			return i
+		}
+
+		index[s] = uint64(len(table))
+		table = append(table, s)
+
+		return index[s]
+	}
+
+	functions := []uint16{profiler.entry}
+	for address := range profiler.subroutines {
+		if address != profiler.entry {
+			functions = append(functions, address)
+		}
+	}
+
+	sort.Slice(functions[1:], func(i, k int) bool {
+		return functions[i+1] < functions[k+1]
+	})
+
+	functionIDs := make(map[uint16]uint64)
+	for i, address := range functions {
+		functionIDs[address] = uint64(i + 1)
+	}
+
+	keys := make([]string, 0, len(profiler.samples))
+	for key := range profiler.samples {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var locations []location
+	locationIDs := make(map[location]uint64)
+	locationID := func(l location) uint64 {
+		if id, ok := locationIDs[l]; ok {
+			return id
+		}
+
+		locations = append(locations, l)
+		locationIDs[l] = uint64(len(locations))
+
+		return locationIDs[l]
+	}
+
+	var p protobuf
+
+	var valueType protobuf
+	valueType.uint(1, str("instructions"))
+	valueType.uint(2, str("count"))
+	p.bytes(PPROF_SAMPLE_TYPE, valueType.buffer)
+
+	for _, key := range keys {
+		s := profiler.samples[key]
+
+		// NOTE: pprof wants the leaf first, the address runs in the innermost subroutine and every call site in its caller
+		function := profiler.entry
+		if len(s.calls) > 0 {
+			function = s.calls[len(s.calls)-1].subroutine
+		}
+
+		ids := []uint64{locationID(location{function, s.address})}
+		for i := len(s.calls) - 1; i >= 0; i-- {
+			caller := profiler.entry
+			if i > 0 {
+				caller = s.calls[i-1].subroutine
+			}
+
+			ids = append(ids, locationID(location{caller, s.calls[i].site}))
+		}
+
+		var message protobuf
+		message.packed(1, ids)
+		message.packed(2, []uint64{s.count})
+		p.bytes(PPROF_SAMPLE, message.buffer)
+	}
+
+	var mapping protobuf
+	mapping.uint(1, 1)
+	mapping.uint(3, 0x10000)
+	mapping.uint(5, str("rom"))
+	mapping.uint(7, 1)
+	mapping.uint(9, 1)
+	p.bytes(PPROF_MAPPING, mapping.buffer)
+
+	for i, l := range locations {
+		var line protobuf
+		line.uint(1, functionIDs[l.function])
+		line.uint(2, uint64(l.address))
+
+		var message protobuf
+		message.uint(1, uint64(i+1))
+		message.uint(2, 1)
+		message.uint(3, uint64(l.address))
+		message.bytes(4, line.buffer)
+		p.bytes(PPROF_LOCATION, message.buffer)
+	}
+
+	for i, address := range functions {
+		name := fmt.Sprintf("sub_0x%03x", address)
+		if i == 0 {
+			name = "main"
+		}
+
+		var message protobuf
+		message.uint(1, uint64(i+1))
+		message.uint(2, str(name))
+		message.uint(3, str(name))
+		message.uint(4, str("rom"))
+		message.uint(5, uint64(address))
+		p.bytes(PPROF_FUNCTION, message.buffer)
+	}
+
+	var periodType protobuf
+	periodType.uint(1, str("instructions"))
+	periodType.uint(2, str("count"))
+
+	for _, s := range table {
+		p.bytes(PPROF_STRING_TABLE, []byte(s))
+	}
+
+	p.bytes(PPROF_PERIOD_TYPE, periodType.buffer)
+	p.uint(PPROF_PERIOD, 1)
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(p.buffer); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+func (profiler *Profiler) SavePprof(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+
+	if err := profiler.WritePprof(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func (p *protobuf) varint(value uint64) {
+	for value >= 0x80 {
+		p.buffer = append(p.buffer, byte(value)|0x80)
+		value >>= 7
+	}
+
+	p.buffer = append(p.buffer, byte(value))
+}
+
+func (p *protobuf) uint(field int, value uint64) {
+	p.varint(uint64(field) << 3)
+	p.varint(value)
+}
+
+func (p *protobuf) bytes(field int, value []byte) {
+	p.varint(uint64(field)<<3 | 2)
+	p.varint(uint64(len(value)))
+	p.buffer = append(p.buffer, value...)
+}
+
+func (p *protobuf) packed(field int, values []uint64) {
+	var message protobuf
+	for _, value := range values {
+		message.varint(value)
+	}
+
+	p.bytes(field, message.buffer)
+}
@@ -0,0 +1,102 @@
+package profile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const PROFILE_HEADER = "miya-profile 1"
+
+// Profile counts how many times the instruction at every address was executed
+type Profile struct {
+	Counts map[uint16]uint64
+}
+
+func New() *Profile {
+	return &Profile{make(map[uint16]uint64)}
+}
+
+func (profile *Profile) Add(address uint16) {
+	profile.Counts[address]++
+}
+
+func (profile *Profile) Addresses() []uint16 {
+	addresses := make([]uint16, 0, len(profile.Counts))
+	for address := range profile.Counts {
+		addresses = append(addresses, address)
+	}
+
+	sort.Slice(addresses, func(i, k int) bool {
+		return addresses[i] < addresses[k]
+	})
+
+	return addresses
+}
+
+func (profile *Profile) Write(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%s\n", PROFILE_HEADER); err != nil {
+		return err
+	}
+
+	for _, address := range profile.Addresses() {
+		if _, err := fmt.Fprintf(w, "0x%03x %d\n", address, profile.Counts[address]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (profile *Profile) Save(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+
+	if err := profile.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func Load(fname string) (*Profile, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Read(file)
+}
+
+func Read(r io.Reader) (*Profile, error) {
+	profile := New()
+
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || scanner.Text() != PROFILE_HEADER {
+		return nil, fmt.Errorf("not a profile, want header %q", PROFILE_HEADER)
+	}
+
+	for line := 2; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var address uint16
+		var count uint64
+
+		if _, err := fmt.Sscanf(scanner.Text(), "0x%x %d", &address, &count); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		profile.Counts[address] += count
+	}
+
+	return profile, scanner.Err()
+}
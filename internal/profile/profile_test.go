@@ -0,0 +1,41 @@
+package profile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	var buffer bytes.Buffer
+
+	profile := New()
+	profile.Add(0x202)
+	profile.Add(0x200)
+	profile.Add(0x202)
+
+	if err := profile.Write(&buffer); err != nil {
+		t.Fatalf("profile.Write(): %v\n", err)
+	}
+
+	if want := PROFILE_HEADER + "\n0x200 1\n0x202 2\n"; buffer.String() != want {
+		t.Errorf("got profile: %q, want profile: %q\n", buffer.String(), want)
+	}
+
+	loaded, err := Read(&buffer)
+	if err != nil {
+		t.Fatalf("Read(): %v\n", err)
+	}
+
+	if loaded.Counts[0x200] != 1 || loaded.Counts[0x202] != 2 {
+		t.Errorf("got counts: %v, want counts: %v\n", loaded.Counts, profile.Counts)
+	}
+}
+
+func TestRead_invalid(t *testing.T) {
+	for _, s := range []string{"", "miya-movie 1\n", PROFILE_HEADER + "\n0x200\n"} {
+		if _, err := Read(strings.NewReader(s)); err == nil {
+			t.Errorf("got err: nil, want err for %q\n", s)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package profile
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const REPORT_TOP = 10
+
+// Profiler follows a running rom and counts the instructions at every address, of every opcode class,
+// of every subroutine and of every frame. Subroutines are tracked by pairing CALL and RET with the depth of the stack
+type Profiler struct {
+	lock         sync.Mutex
+	profile      *Profile
+	entry        uint16
+	classes      map[string]uint64
+	subroutines  map[uint16]*Subroutine
+	calls        []call
+	samples      map[string]*sample
+	key          []byte
+	instructions uint64
+	frame        uint64
+	frames       []uint64
+}
+
+type Subroutine struct {
+	Address uint16
+	Calls   uint64
+	Self    uint64
+	Total   uint64
+}
+
+type call struct {
+	subroutine uint16
+	site       uint16
+	start      uint64
+}
+
+// NOTE: A sample is a call stack, the call sites from the outermost call followed by the address that was executed
+type sample struct {
+	calls   []call
+	address uint16
+	count   uint64
+}
+
+func NewProfiler(entry uint16) *Profiler {
+	return &Profiler{
+		profile:     New(),
+		entry:       entry,
+		classes:     make(map[string]uint64),
+		subroutines: make(map[uint16]*Subroutine),
+		samples:     make(map[string]*sample),
+	}
+}
+
+func (profiler *Profiler) Step(address uint16, class string) {
+	profiler.lock.Lock()
+	defer profiler.lock.Unlock()
+
+	profiler.profile.Add(address)
+	profiler.classes[class]++
+	profiler.instructions++
+	profiler.frame++
+
+	if len(profiler.calls) > 0 {
+		profiler.subroutines[profiler.calls[len(profiler.calls)-1].subroutine].Self++
+	}
+
+	profiler.key = profiler.key[:0]
+	for _, call := range profiler.calls {
+		profiler.key = append(profiler.key, byte(call.subroutine>>8), byte(call.subroutine), byte(call.site>>8), byte(call.site))
+	}
+
+	profiler.key = append(profiler.key, byte(address>>8), byte(address))
+
+	if s, ok := profiler.samples[string(profiler.key)]; ok {
+		s.count++
+		return
+	}
+
+	profiler.samples[string(profiler.key)] = &sample{append([]call(nil), profiler.calls...), address, 1}
+}
+
+// NOTE: depth is the depth of the stack after the call, a push on a full stack is lost, so is the call it would return from
+func (profiler *Profiler) Call(site, subroutine uint16, depth int) {
+	profiler.lock.Lock()
+	defer profiler.lock.Unlock()
+
+	profiler.unwind(depth - 1)
+	profiler.calls = append(profiler.calls, call{subroutine, site, profiler.instructions})
+
+	if _, ok := profiler.subroutines[subroutine]; !ok {
+		profiler.subroutines[subroutine] = &Subroutine{Address: subroutine}
+	}
+
+	profiler.subroutines[subroutine].Calls++
+}
+
+func (profiler *Profiler) Return(depth int) {
+	profiler.lock.Lock()
+	defer profiler.lock.Unlock()
+
+	profiler.unwind(depth)
+}
+
+func (profiler *Profiler) EndFrame() {
+	profiler.lock.Lock()
+	defer profiler.lock.Unlock()
+
+	profiler.frames = append(profiler.frames, profiler.frame)
+	profiler.frame = 0
+}
+
+// NOTE: Recursive calls are only charged to the outermost call, otherwise their instructions would be counted twice
+func (profiler *Profiler) unwind(depth int) {
+	if depth < 0 {
+		depth = 0
+	}
+
+	for len(profiler.calls) > depth {
+		last := profiler.calls[len(profiler.calls)-1]
+		profiler.calls = profiler.calls[:len(profiler.calls)-1]
+
+		if !active(profiler.calls, last.subroutine) {
+			profiler.subroutines[last.subroutine].Total += profiler.instructions - last.start
+		}
+	}
+}
+
+func (profiler *Profiler) Profile() *Profile {
+	profiler.lock.Lock()
+	defer profiler.lock.Unlock()
+
+	profile := New()
+	for address, count := range profiler.profile.Counts {
+		profile.Counts[address] = count
+	}
+
+	return profile
+}
+
+func (profiler *Profiler) Subroutines() []Subroutine {
+	profiler.lock.Lock()
+	defer profiler.lock.Unlock()
+
+	return profiler.sortedSubroutines()
+}
+
+// NOTE: Subroutines that have not returned yet are charged up to now
+func (profiler *Profiler) sortedSubroutines() []Subroutine {
+	subroutines := make([]Subroutine, 0, len(profiler.subroutines))
+	for _, subroutine := range profiler.subroutines {
+		s := *subroutine
+
+		for i, call := range profiler.calls {
+			if call.subroutine == s.Address && !active(profiler.calls[:i], s.Address) {
+				s.Total += profiler.instructions - call.start
+			}
+		}
+
+		subroutines = append(subroutines, s)
+	}
+
+	sort.Slice(subroutines, func(i, k int) bool {
+		if subroutines[i].Total != subroutines[k].Total {
+			return subroutines[i].Total > subroutines[k].Total
+		}
+
+		return subroutines[i].Address < subroutines[k].Address
+	})
+
+	return subroutines
+}
+
+func active(calls []call, subroutine uint16) bool {
+	for _, call := range calls {
+		if call.subroutine == subroutine {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (profiler *Profiler) Frames() []uint64 {
+	profiler.lock.Lock()
+	defer profiler.lock.Unlock()
+
+	return append([]uint64(nil), profiler.frames...)
+}
+
+func (profiler *Profiler) Report(w io.Writer) error {
+	profiler.lock.Lock()
+	defer profiler.lock.Unlock()
+
+	profile := profiler.profile
+	subroutines := profiler.sortedSubroutines()
+	frames := profiler.frames
+	instructions := profiler.instructions
+	classes := profiler.classes
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Instructions: %d\n", instructions)
+
+	if len(frames) > 0 {
+		min, max, total := frames[0], frames[0], uint64(0)
+		for _, count := range frames {
+			if count < min {
+				min = count
+			}
+
+			if count > max {
+				max = count
+			}
+
+			total += count
+		}
+
+		fmt.Fprintf(&b, "Frames:       %d, %.1f instructions per frame, min %d, max %d\n", len(frames), float64(total)/float64(len(frames)), min, max)
+	}
+
+	addresses := profile.Addresses()
+	sort.SliceStable(addresses, func(i, k int) bool {
+		return profile.Counts[addresses[i]] > profile.Counts[addresses[k]]
+	})
+
+	fmt.Fprintf(&b, "Top addresses:\n")
+	for i := 0; i < len(addresses) && i < REPORT_TOP; i++ {
+		count := profile.Counts[addresses[i]]
+		fmt.Fprintf(&b, "  0x%03x %10d %5.1f%%\n", addresses[i], count, percent(count, instructions))
+	}
+
+	fmt.Fprintf(&b, "Top subroutines:\n")
+	if len(subroutines) == 0 {
+		fmt.Fprintf(&b, "  none\n")
+	}
+
+	for i := 0; i < len(subroutines) && i < REPORT_TOP; i++ {
+		s := subroutines[i]
+		fmt.Fprintf(&b, "  0x%03x calls %8d, self %10d, total %10d %5.1f%%\n", s.Address, s.Calls, s.Self, s.Total, percent(s.Total, instructions))
+	}
+
+	names := make([]string, 0, len(classes))
+	for class := range classes {
+		names = append(names, class)
+	}
+
+	sort.Slice(names, func(i, k int) bool {
+		if classes[names[i]] != classes[names[k]] {
+			return classes[names[i]] > classes[names[k]]
+		}
+
+		return names[i] < names[k]
+	})
+
+	fmt.Fprintf(&b, "Opcode classes:\n")
+	for _, class := range names {
+		fmt.Fprintf(&b, "  %-5s %10d %5.1f%%\n", class, classes[class], percent(classes[class], instructions))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func percent(count, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return 100 * float64(count) / float64(total)
+}
@@ -0,0 +1,107 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+)
+
+// 0x200 CALL 0x206, 0x202 JP 0x200, 0x206 CALL 0x20a, 0x208 RET, 0x20a RET
+func runProfiler() *Profiler {
+	profiler := NewProfiler(0x200)
+
+	profiler.Step(0x200, "2NNN")
+	profiler.Call(0x200, 0x206, 1)
+	profiler.Step(0x206, "2NNN")
+	profiler.Call(0x206, 0x20a, 2)
+	profiler.Step(0x20a, "00EE")
+	profiler.Return(1)
+	profiler.Step(0x208, "00EE")
+	profiler.Return(0)
+	profiler.Step(0x202, "1NNN")
+	profiler.EndFrame()
+
+	return profiler
+}
+
+func TestProfiler(t *testing.T) {
+	profiler := runProfiler()
+
+	if count := profiler.Profile().Counts[0x206]; count != 1 {
+		t.Errorf("got count: %d, want count: %d\n", count, 1)
+	}
+
+	subroutines := profiler.Subroutines()
+	if len(subroutines) != 2 {
+		t.Fatalf("got %d subroutines, want %d\n", len(subroutines), 2)
+	}
+
+	if s := subroutines[0]; s.Address != 0x206 || s.Calls != 1 || s.Self != 2 || s.Total != 3 {
+		t.Errorf("got subroutine: %+v, want 0x206 with 1 call, self 2, total 3\n", s)
+	}
+
+	if s := subroutines[1]; s.Address != 0x20a || s.Self != 1 || s.Total != 1 {
+		t.Errorf("got subroutine: %+v, want 0x20a with self 1, total 1\n", s)
+	}
+
+	if frames := profiler.Frames(); len(frames) != 1 || frames[0] != 5 {
+		t.Errorf("got frames: %v, want frames: %v\n", frames, []uint64{5})
+	}
+}
+
+func TestProfiler_recursion(t *testing.T) {
+	profiler := NewProfiler(0x200)
+
+	profiler.Step(0x200, "2NNN")
+	profiler.Call(0x200, 0x204, 1)
+	profiler.Step(0x204, "2NNN")
+	profiler.Call(0x204, 0x204, 2)
+	profiler.Step(0x204, "00EE")
+	profiler.Return(1)
+	profiler.Step(0x206, "00EE")
+	profiler.Return(0)
+
+	if s := profiler.Subroutines()[0]; s.Calls != 2 || s.Total != 3 {
+		t.Errorf("got subroutine: %+v, want 2 calls, total 3\n", s)
+	}
+}
+
+func TestProfiler_Report(t *testing.T) {
+	var buffer bytes.Buffer
+
+	if err := runProfiler().Report(&buffer); err != nil {
+		t.Fatalf("profiler.Report(): %v\n", err)
+	}
+
+	for _, want := range []string{"Instructions: 5\n", "0x206 calls        1, self          2, total          3  60.0%", "2NNN           2  40.0%"} {
+		if !strings.Contains(buffer.String(), want) {
+			t.Errorf("got report: %q, want it to contain %q\n", buffer.String(), want)
+		}
+	}
+}
+
+func TestProfiler_WritePprof(t *testing.T) {
+	var buffer bytes.Buffer
+
+	if err := runProfiler().WritePprof(&buffer); err != nil {
+		t.Fatalf("profiler.WritePprof(): %v\n", err)
+	}
+
+	reader, err := gzip.NewReader(&buffer)
+	if err != nil {
+		t.Fatalf("gzip.NewReader(): %v\n", err)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("io.ReadAll(): %v\n", err)
+	}
+
+	for _, want := range []string{"instructions", "main", "sub_0x206", "sub_0x20a"} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("got no %q in the string table, want it\n", want)
+		}
+	}
+}
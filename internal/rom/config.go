@@ -0,0 +1,46 @@
+package rom
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type Config struct {
+	Palette string `json:"palette,omitempty"`
+}
+
+// NOTE: Configs are keyed by the sha1 of the rom, so renamed or moved roms keep their settings
+func ConfigFilename(dir string, buffer []byte) string {
+	return filepath.Join(dir, SHA1(buffer)+".json")
+}
+
+func LoadConfig(fname string) (Config, error) {
+	var config Config
+
+	buffer, err := os.ReadFile(fname)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+
+	if err != nil {
+		return config, err
+	}
+
+	return config, json.Unmarshal(buffer, &config)
+}
+
+func SaveConfig(fname string, config Config) error {
+	if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+		return err
+	}
+
+	buffer, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(fname, append(buffer, '\n'), 0644)
+}
@@ -0,0 +1,24 @@
+package rom
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	fname := ConfigFilename(filepath.Join(t.TempDir(), "roms"), []byte{0x00, 0xE0})
+
+	config, err := LoadConfig(fname)
+	if err != nil || config.Palette != "" {
+		t.Fatalf("got config: %v, %v, want config: %v, %v\n", config, err, Config{}, nil)
+	}
+
+	if err := SaveConfig(fname, Config{Palette: "amber"}); err != nil {
+		t.Fatalf("SaveConfig(): %v\n", err)
+	}
+
+	config, err = LoadConfig(fname)
+	if err != nil || config.Palette != "amber" {
+		t.Errorf("got palette: %s, %v, want palette: %s, %v\n", config.Palette, err, "amber", nil)
+	}
+}
@@ -0,0 +1,67 @@
+package rom
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+type Info struct {
+	Title       string
+	Description string
+	Platforms   []string
+}
+
+type Database struct {
+	roms map[string]Info
+}
+
+// programs.json of the CHIP-8 database: a list of programs, each with the sha1 of its roms
+type program struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Roms        map[string]struct {
+		Platforms []string `json:"platforms"`
+	} `json:"roms"`
+}
+
+func LoadDatabase(fname string) (*Database, error) {
+	buffer, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	var programs []program
+	if err := json.Unmarshal(buffer, &programs); err != nil {
+		return nil, err
+	}
+
+	db := Database{make(map[string]Info)}
+	for _, p := range programs {
+		for hash, r := range p.Roms {
+			db.roms[strings.ToLower(hash)] = Info{
+				Title:       p.Title,
+				Description: p.Description,
+				Platforms:   r.Platforms,
+			}
+		}
+	}
+
+	return &db, nil
+}
+
+func (db *Database) Lookup(buffer []byte) (Info, bool) {
+	if db == nil {
+		return Info{}, false
+	}
+
+	info, ok := db.roms[SHA1(buffer)]
+	return info, ok
+}
+
+func SHA1(buffer []byte) string {
+	sum := sha1.Sum(buffer)
+	return hex.EncodeToString(sum[:])
+}
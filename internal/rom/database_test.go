@@ -0,0 +1,52 @@
+package rom
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const programsJSON = `[
+	{
+		"title": "Pong",
+		"description": "Two paddles",
+		"roms": {
+			"%s": {"platforms": ["originalChip8"]}
+		}
+	}
+]`
+
+func TestLoadDatabase(t *testing.T) {
+	rom := []byte{0x12, 0x00}
+	fname := filepath.Join(t.TempDir(), "programs.json")
+	os.WriteFile(fname, []byte(fmt.Sprintf(programsJSON, SHA1(rom))), 0644)
+
+	db, err := LoadDatabase(fname)
+	if err != nil {
+		t.Fatalf("LoadDatabase(): %v\n", err)
+	}
+
+	info, ok := db.Lookup(rom)
+	if !ok || info.Title != "Pong" || info.Description != "Two paddles" || len(info.Platforms) != 1 || info.Platforms[0] != "originalChip8" {
+		t.Errorf("got info: %v, found: %t, want info for Pong\n", info, ok)
+	}
+
+	if _, ok := db.Lookup([]byte{0x00}); ok {
+		t.Errorf("got found: true for an unknown rom, want found: false\n")
+	}
+}
+
+func TestLookup_nil(t *testing.T) {
+	var db *Database
+
+	if _, ok := db.Lookup([]byte{0x00}); ok {
+		t.Errorf("got found: true without a database, want found: false\n")
+	}
+}
+
+func TestSHA1(t *testing.T) {
+	if hash := SHA1([]byte("abc")); hash != "a9993e364706816aba3e25717850c26c9cd0d89d" {
+		t.Errorf("got sha1: %s, want sha1: %s\n", hash, "a9993e364706816aba3e25717850c26c9cd0d89d")
+	}
+}
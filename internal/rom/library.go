@@ -0,0 +1,122 @@
+package rom
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const RECENT_SIZE = 10
+
+var Extensions = []string{".ch8", ".c8", ".sc8", ".xo8"}
+
+type Entry struct {
+	Path string
+	Info Info
+}
+
+// NOTE: A file or a directory that can't be read is logged and skipped, only a library that can't be read at all is an error
+func Scan(dir string, db *Database) ([]Entry, error) {
+	var entries []Entry
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+
+			log.Printf("rom.Scan(): %v\n", err)
+
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if d.IsDir() || !isRom(path) {
+			return nil
+		}
+
+		entry, err := NewEntry(path, db)
+		if err != nil {
+			log.Printf("rom.NewEntry(): %v\n", err)
+			return nil
+		}
+
+		entries = append(entries, entry)
+
+		return nil
+	})
+
+	sort.Slice(entries, func(i, k int) bool {
+		return strings.ToLower(entries[i].Info.Title) < strings.ToLower(entries[k].Info.Title)
+	})
+
+	return entries, err
+}
+
+func NewEntry(path string, db *Database) (Entry, error) {
+	buffer, err := os.ReadFile(path)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	info, ok := db.Lookup(buffer)
+	if !ok {
+		info.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return Entry{path, info}, nil
+}
+
+func isRom(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+func LoadRecent(fname string) []string {
+	buffer, err := os.ReadFile(fname)
+	if err != nil {
+		return nil
+	}
+
+	var recent []string
+	for _, path := range strings.Split(string(buffer), "\n") {
+		if path != "" {
+			recent = append(recent, path)
+		}
+	}
+
+	return recent
+}
+
+func AddRecent(recent []string, path string) []string {
+	result := []string{path}
+
+	for _, p := range recent {
+		if p != path && len(result) < RECENT_SIZE {
+			result = append(result, p)
+		}
+	}
+
+	return result
+}
+
+func SaveRecent(fname string, recent []string) error {
+	if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(fname, []byte(strings.Join(recent, "\n")+"\n"), 0644)
+}
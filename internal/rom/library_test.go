@@ -0,0 +1,83 @@
+package rom
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "Tetris.ch8"), []byte{0x12, 0x00}, 0644)
+	os.WriteFile(filepath.Join(dir, "breakout.ch8"), []byte{0x12, 0x02}, 0644)
+	os.WriteFile(filepath.Join(dir, "README.txt"), []byte("readme"), 0644)
+	os.WriteFile(filepath.Join(dir, "source.8o"), []byte(": main"), 0644)
+
+	entries, err := Scan(dir, nil)
+	if err != nil {
+		t.Fatalf("Scan(): %v\n", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("got entries: %d, want entries: %d\n", len(entries), 2)
+	}
+
+	if entries[0].Info.Title != "breakout" || entries[1].Info.Title != "Tetris" {
+		t.Errorf("got titles: %s, %s, want titles: %s, %s\n", entries[0].Info.Title, entries[1].Info.Title, "breakout", "Tetris")
+	}
+}
+
+func TestScan_unreadable(t *testing.T) {
+	var buffer bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buffer)
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "Tetris.ch8"), []byte{0x12, 0x00}, 0644)
+	os.Symlink(filepath.Join(dir, "missing.ch8"), filepath.Join(dir, "broken.ch8"))
+
+	entries, err := Scan(dir, nil)
+	if err != nil {
+		t.Fatalf("Scan(): %v\n", err)
+	}
+
+	if len(entries) != 1 || entries[0].Info.Title != "Tetris" {
+		t.Errorf("got entries: %v, want the readable rom only\n", entries)
+	}
+
+	if !strings.Contains(buffer.String(), "broken.ch8") {
+		t.Errorf("got log: %q, want the unreadable rom logged\n", buffer.String())
+	}
+
+	if _, err := Scan(filepath.Join(dir, "missing"), nil); err == nil {
+		t.Errorf("got err: nil, want err for a missing library\n")
+	}
+}
+
+func TestRecent(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "miya", "recent")
+
+	recent := AddRecent(nil, "a.ch8")
+	recent = AddRecent(recent, "b.ch8")
+	recent = AddRecent(recent, "a.ch8")
+
+	if err := SaveRecent(fname, recent); err != nil {
+		t.Fatalf("SaveRecent(): %v\n", err)
+	}
+
+	loaded := LoadRecent(fname)
+	if len(loaded) != 2 || loaded[0] != "a.ch8" || loaded[1] != "b.ch8" {
+		t.Errorf("got recent: %v, want recent: %v\n", loaded, []string{"a.ch8", "b.ch8"})
+	}
+
+	for i := 0; i < RECENT_SIZE+5; i++ {
+		recent = AddRecent(recent, filepath.Join("roms", string(rune('a'+i))))
+	}
+
+	if len(recent) != RECENT_SIZE {
+		t.Errorf("got recent: %d, want recent: %d\n", len(recent), RECENT_SIZE)
+	}
+}
@@ -0,0 +1,180 @@
+package screen
+
+import (
+	"log"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const BROWSER_LINE_HEIGHT = 18
+const BROWSER_PAGE = 10
+
+type BrowserItem struct {
+	Title       string
+	Detail      string
+	Description string
+}
+
+type Browser struct {
+	items    []BrowserItem
+	selected int
+	active   bool
+	launch   func(index int) error
+	stop     func()
+}
+
+func NewBrowser(items []BrowserItem, launch func(index int) error, stop func()) (*Browser, error) {
+	if err := sdl.InitSubSystem(sdl.INIT_GAMECONTROLLER); err != nil {
+		return nil, err
+	}
+
+	return &Browser{
+		items:  items,
+		active: true,
+		launch: launch,
+		stop:   stop,
+	}, nil
+}
+
+func (browser *Browser) Active() bool {
+	return browser.active
+}
+
+func (browser *Browser) Move(delta int) {
+	if len(browser.items) == 0 {
+		return
+	}
+
+	browser.selected += delta
+
+	if browser.selected < 0 {
+		browser.selected = 0
+	}
+
+	if browser.selected >= len(browser.items) {
+		browser.selected = len(browser.items) - 1
+	}
+}
+
+func (browser *Browser) Select() {
+	if len(browser.items) == 0 {
+		return
+	}
+
+	if err := browser.launch(browser.selected); err != nil {
+		log.Printf("browser.launch(): %v\n", err)
+		return
+	}
+
+	browser.active = false
+}
+
+func (browser *Browser) Back() {
+	if browser.active {
+		return
+	}
+
+	browser.stop()
+	browser.active = true
+}
+
+func (browser *Browser) key(keycode sdl.Keycode) {
+	switch keycode {
+	case sdl.K_UP:
+		browser.Move(-1)
+	case sdl.K_DOWN:
+		browser.Move(1)
+	case sdl.K_PAGEUP:
+		browser.Move(-BROWSER_PAGE)
+	case sdl.K_PAGEDOWN:
+		browser.Move(BROWSER_PAGE)
+	case sdl.K_RETURN:
+		browser.Select()
+	}
+}
+
+func (browser *Browser) button(button uint8) {
+	if !browser.active {
+		if button == sdl.CONTROLLER_BUTTON_B || button == sdl.CONTROLLER_BUTTON_BACK {
+			browser.Back()
+		}
+
+		return
+	}
+
+	switch button {
+	case sdl.CONTROLLER_BUTTON_DPAD_UP:
+		browser.Move(-1)
+	case sdl.CONTROLLER_BUTTON_DPAD_DOWN:
+		browser.Move(1)
+	case sdl.CONTROLLER_BUTTON_LEFTSHOULDER:
+		browser.Move(-BROWSER_PAGE)
+	case sdl.CONTROLLER_BUTTON_RIGHTSHOULDER:
+		browser.Move(BROWSER_PAGE)
+	case sdl.CONTROLLER_BUTTON_A, sdl.CONTROLLER_BUTTON_START:
+		browser.Select()
+	}
+}
+
+func (mw *MainWindow) drawBrowser() {
+	_, height := mw.window.GetSize()
+	lines := int(height/BROWSER_LINE_HEIGHT) - 3
+	items := mw.browser.items
+
+	if len(items) == 0 {
+		mw.drawText("No roms found", 4, 4, false)
+		return
+	}
+
+	first := mw.browser.selected - lines/2
+	if first > len(items)-lines {
+		first = len(items) - lines
+	}
+
+	if first < 0 {
+		first = 0
+	}
+
+	for i := first; i < len(items) && i < first+lines; i++ {
+		text := items[i].Title
+		if items[i].Detail != "" {
+			text += " [" + items[i].Detail + "]"
+		}
+
+		mw.drawText(text, 4, int32(i-first)*BROWSER_LINE_HEIGHT+4, i == mw.browser.selected)
+	}
+
+	if description := items[mw.browser.selected].Description; description != "" {
+		mw.drawText(description, 4, height-2*BROWSER_LINE_HEIGHT, false)
+	}
+}
+
+func (mw *MainWindow) drawText(text string, x, y int32, inverted bool) {
+	if mw.font == nil {
+		return
+	}
+
+	foreground, background := mw.palette.Pixel(), mw.palette.Background()
+	if inverted {
+		foreground, background = background, foreground
+	}
+
+	surface, err := mw.font.RenderUTF8Shaded(text, foreground, background)
+	if err != nil {
+		return
+	}
+	defer surface.Free()
+
+	texture, err := mw.renderer.CreateTextureFromSurface(surface)
+	if err != nil {
+		return
+	}
+	defer texture.Destroy()
+
+	mw.renderer.Copy(texture, nil, &sdl.Rect{
+		X: x,
+		Y: y,
+		W: surface.W,
+		H: surface.H,
+	})
+}
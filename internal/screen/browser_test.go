@@ -0,0 +1,59 @@
+package screen
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBrowser(t *testing.T) {
+	var launched, stopped int
+
+	browser := &Browser{
+		items:  []BrowserItem{{Title: "Pong"}, {Title: "Tetris"}, {Title: "Brix"}},
+		active: true,
+		launch: func(index int) error {
+			launched = index
+			return nil
+		},
+		stop: func() {
+			stopped++
+		},
+	}
+
+	browser.Move(-1)
+	browser.Move(BROWSER_PAGE)
+
+	if browser.selected != 2 {
+		t.Errorf("got selected: %d, want selected: %d\n", browser.selected, 2)
+	}
+
+	browser.Move(-1)
+	browser.Select()
+
+	if launched != 1 || browser.Active() {
+		t.Errorf("got launched: %d, active: %t, want launched: %d, active: false\n", launched, browser.Active(), 1)
+	}
+
+	browser.Back()
+	browser.Back()
+
+	if stopped != 1 || !browser.Active() {
+		t.Errorf("got stopped: %d, active: %t, want stopped: %d, active: true\n", stopped, browser.Active(), 1)
+	}
+}
+
+func TestBrowser_launchError(t *testing.T) {
+	browser := &Browser{
+		items:  []BrowserItem{{Title: "Pong"}},
+		active: true,
+		launch: func(index int) error {
+			return errors.New("no such file")
+		},
+	}
+
+	browser.Select()
+
+	if !browser.Active() {
+		t.Errorf("got active: false after a failed launch, want active: true\n")
+	}
+}
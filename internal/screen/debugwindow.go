@@ -1,6 +1,7 @@
 package screen
 
 import (
+	"miya/internal/memory"
 	"strings"
 
 	"github.com/veandco/go-sdl2/sdl"
@@ -8,14 +9,32 @@ import (
 )
 
 const DEBUG_BUTTON_X = 160
-const DEBUG_BUTTON_Y = 90
 const DEBUG_BUTTON_W = 60
 const DEBUG_BUTTON_H = 20
+const DEBUG_LINE_HEIGHT = 10
+const DEBUG_MARGIN = 10
+
+// NOTE: The first 4KB of memory are drawn as 64 rows of 64 bytes below the registers and the backtrace
+const HEATMAP_COLUMNS = 64
+const HEATMAP_BYTES = 0x1000
+const HEATMAP_CELL = 3
 
 type DebugWindow struct {
 	window   *sdl.Window
 	renderer *sdl.Renderer
 	font     *ttf.Font
+	heatmap  memory.Heatmap
+	buttonY  int32
+}
+
+var accessColors = []struct {
+	access memory.Access
+	color  sdl.Color
+}{
+	{memory.ACCESS_SMC, sdl.Color{R: 255, G: 0, B: 0, A: 255}},
+	{memory.ACCESS_WRITE, sdl.Color{R: 255, G: 160, B: 0, A: 255}},
+	{memory.ACCESS_EXECUTE, sdl.Color{R: 0, G: 255, B: 0, A: 255}},
+	{memory.ACCESS_READ, sdl.Color{R: 0, G: 128, B: 255, A: 255}},
 }
 
 func NewDebugWindow(title string, width, height int32) (*DebugWindow, error) {
@@ -47,13 +66,17 @@ func NewDebugWindow(title string, width, height int32) (*DebugWindow, error) {
 	return &dw, nil
 }
 
+// NOTE: The backtrace grows with the depth of the stack, so the button and the heatmap go below the text and the
+// window grows until they fit
 func (dw *DebugWindow) Render() {
-	for i, info := range strings.Split(<-Debug, "\n") {
+	lines := strings.Split(<-Debug, "\n")
+
+	for i, info := range lines {
 		surface, _ := dw.font.RenderUTF8Solid(info, sdl.Color{R: 255, G: 255, B: 255, A: 255})
 		texture, _ := dw.renderer.CreateTextureFromSurface(surface)
 		rect := sdl.Rect{
 			X: 0,
-			Y: int32(i * 10),
+			Y: int32(i * DEBUG_LINE_HEIGHT),
 			W: surface.W,
 			H: surface.H,
 		}
@@ -63,18 +86,38 @@ func (dw *DebugWindow) Render() {
 		texture.Destroy()
 	}
 
+	select {
+	case dw.heatmap = <-Heatmap:
+	default:
+	}
+
+	dw.buttonY = int32(len(lines)*DEBUG_LINE_HEIGHT) + DEBUG_MARGIN
+	heatmapY := dw.buttonY + DEBUG_BUTTON_H + DEBUG_MARGIN
+	dw.fit(heatmapY + HEATMAP_BYTES/HEATMAP_COLUMNS*HEATMAP_CELL)
+
 	dw.drawNextButton()
+	dw.drawHeatmap(heatmapY)
 	dw.renderer.Present()
 	dw.renderer.Clear()
 }
 
+func (dw *DebugWindow) fit(height int32) {
+	if width, current := dw.window.GetSize(); current < height {
+		dw.window.SetSize(width, height)
+	}
+}
+
+func (dw *DebugWindow) nextClicked(x, y int32) bool {
+	return x >= DEBUG_BUTTON_X && x <= DEBUG_BUTTON_X+DEBUG_BUTTON_W && y >= dw.buttonY && y <= dw.buttonY+DEBUG_BUTTON_H
+}
+
 func (dw *DebugWindow) drawNextButton() {
 	dw.renderer.SetDrawColor(255, 255, 255, 255)
 	defer dw.renderer.SetDrawColor(0, 0, 0, 0)
 
 	dw.renderer.FillRect(&sdl.Rect{
 		X: DEBUG_BUTTON_X,
-		Y: DEBUG_BUTTON_Y,
+		Y: dw.buttonY,
 		W: DEBUG_BUTTON_W,
 		H: DEBUG_BUTTON_H,
 	})
@@ -83,7 +126,7 @@ func (dw *DebugWindow) drawNextButton() {
 	texture, _ := dw.renderer.CreateTextureFromSurface(surface)
 	rect := sdl.Rect{
 		X: DEBUG_BUTTON_X + 15,
-		Y: DEBUG_BUTTON_Y + 3,
+		Y: dw.buttonY + 3,
 		W: surface.W,
 		H: surface.H,
 	}
@@ -93,6 +136,38 @@ func (dw *DebugWindow) drawNextButton() {
 	texture.Destroy()
 }
 
+// NOTE: Every byte has the color of its most telling access, self-modifying code first, and is brighter the more recent the access was
+func (dw *DebugWindow) drawHeatmap(y int32) {
+	defer dw.renderer.SetDrawColor(0, 0, 0, 0)
+
+	for i, access := range dw.heatmap.Access {
+		if i >= HEATMAP_BYTES {
+			break
+		}
+
+		if access == 0 {
+			continue
+		}
+
+		var color sdl.Color
+		for _, c := range accessColors {
+			if access&c.access != 0 {
+				color = c.color
+				break
+			}
+		}
+
+		level := 64 + uint32(dw.heatmap.Heat[i])*191/memory.HEAT_MAX
+		dw.renderer.SetDrawColor(uint8(uint32(color.R)*level/0xFF), uint8(uint32(color.G)*level/0xFF), uint8(uint32(color.B)*level/0xFF), 255)
+		dw.renderer.FillRect(&sdl.Rect{
+			X: int32(i%HEATMAP_COLUMNS) * HEATMAP_CELL,
+			Y: y + int32(i/HEATMAP_COLUMNS)*HEATMAP_CELL,
+			W: HEATMAP_CELL,
+			H: HEATMAP_CELL,
+		})
+	}
+}
+
 func (dw *DebugWindow) Free() {
 	dw.window.Destroy()
 	dw.renderer.Destroy()
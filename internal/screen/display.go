@@ -0,0 +1,170 @@
+package screen
+
+import (
+	"image"
+	"image/color"
+)
+
+const LEVELS = 32
+const FILTER_SCALE = 8
+
+type Filter struct {
+	Persistence int
+	Blend       bool
+	Scanlines   bool
+	Grid        bool
+}
+
+// NOTE: Brightness of every pixel, from 0 for the background color to LEVELS-1 for the pixel color. The entries
+// of the palette past the pixel color follow at LEVELS, each at full and at half brightness
+type Levels [WIDTH][HEIGHT]byte
+
+// NOTE: Update is called once per 60Hz frame, so persistence fades at the same speed in the window, recordings and headless mode
+type Display struct {
+	filter    Filter
+	palette   Palette
+	intensity [WIDTH][HEIGHT]float64
+	frame     Frame
+	previous  Frame
+	levels    Levels
+}
+
+func NewDisplay(filter Filter, palette Palette) *Display {
+	return &Display{
+		filter:  filter,
+		palette: palette,
+	}
+}
+
+func (f Filter) Spatial() bool {
+	return f.Scanlines || f.Grid
+}
+
+func (display *Display) Update(frame Frame) {
+	display.previous = display.frame
+	display.frame = frame
+
+	for i := 0; i < HEIGHT; i++ {
+		for k := 0; k < WIDTH; k++ {
+			// NOTE: Only the pixel color fades and blends, the other entries of the palette are shown as they are
+			index := display.palette.index(display.frame[k][i])
+			if index > 1 {
+				display.intensity[k][i] = 1
+				display.levels[k][i] = byte(LEVELS + 2*(index-2))
+				continue
+			}
+
+			target := float64(index)
+
+			if display.filter.Blend {
+				previous := 0.0
+				if display.palette.index(display.previous[k][i]) != 0 {
+					previous = 1
+				}
+
+				target = (target + previous) / 2
+			}
+
+			if display.filter.Persistence > 0 {
+				faded := display.intensity[k][i] - 1/float64(display.filter.Persistence)
+				if faded > target {
+					target = faded
+				}
+			}
+
+			display.intensity[k][i] = target
+			display.levels[k][i] = byte(target*(LEVELS-1) + 0.5)
+		}
+	}
+}
+
+func (display *Display) Frame() Frame {
+	return display.frame
+}
+
+func (display *Display) Levels() Levels {
+	return display.levels
+}
+
+func (display *Display) Filter() Filter {
+	return display.filter
+}
+
+func (display *Display) Palette() Palette {
+	return display.palette
+}
+
+func (display *Display) SetPalette(palette Palette) {
+	display.palette = palette
+}
+
+func (display *Display) Image(levels Levels, scale int) *image.Paletted {
+	return filterImage(levels, display.filter, display.palette, scale)
+}
+
+// NOTE: The levels mix the background and the pixel color, the other entries of the palette come after them
+func (palette Palette) levels() color.Palette {
+	colors := make(color.Palette, LEVELS, LEVELS+2*(len(palette)-2))
+	background, pixel := opaque(palette.Background()), opaque(palette.Pixel())
+
+	for i := range colors {
+		colors[i] = blend(background, pixel, i)
+	}
+
+	for _, entry := range palette[2:] {
+		colors = append(colors, blend(background, opaque(entry), LEVELS-1), blend(background, opaque(entry), LEVELS/2))
+	}
+
+	return colors
+}
+
+func blend(background, pixel color.RGBA, level int) color.RGBA {
+	return color.RGBA{
+		R: mix(background.R, pixel.R, level),
+		G: mix(background.G, pixel.G, level),
+		B: mix(background.B, pixel.B, level),
+		A: 0xFF,
+	}
+}
+
+// NOTE: The entries past the pixel color have their half brightness right after them
+func darken(level byte) byte {
+	if level < LEVELS {
+		return level / 2
+	}
+
+	return level | 1
+}
+
+// NOTE: The scale is per lores pixel, so a hires pixel is scale/2 wide. Scanlines and the grid halve the brightness,
+// so every color stays between the background and the pixel color. Levels of an entry the palette doesn't have, left
+// from a bigger palette until the next update, are drawn in the pixel color
+func filterImage(levels Levels, filter Filter, palette Palette, scale int) *image.Paletted {
+	colors := palette.levels()
+	img := image.NewPaletted(image.Rect(0, 0, LORES_WIDTH*scale, LORES_HEIGHT*scale), colors)
+
+	for i := 0; i < LORES_HEIGHT*scale; i++ {
+		for k := 0; k < LORES_WIDTH*scale; k++ {
+			level := levels[k*2/scale][i*2/scale]
+			if int(level) >= len(colors) {
+				level = LEVELS - 1
+			}
+
+			if filter.Scanlines && scale >= 2 && (i%scale)%2 == 1 {
+				level = darken(level)
+			}
+
+			if filter.Grid && scale >= 3 && (i%scale == scale-1 || k%scale == scale-1) {
+				level = darken(level)
+			}
+
+			img.SetColorIndex(k, i, level)
+		}
+	}
+
+	return img
+}
+
+func mix(a, b byte, level int) byte {
+	return byte((int(a)*(LEVELS-1-level) + int(b)*level) / (LEVELS - 1))
+}
@@ -0,0 +1,109 @@
+package screen
+
+import (
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestDisplay(t *testing.T) {
+	mock := &MockWindow{}
+	mock.SetPixel(0x00, 0x00)
+
+	display := NewDisplay(Filter{}, NewPalette(sdl.Color{}, sdl.Color{R: 0xFF, G: 0xFF, B: 0xFF}))
+	display.Update(mock.Frame())
+
+	// NOTE: A lores pixel covers 2x2 hires levels
+	if levels := display.Levels(); levels[1][1] != LEVELS-1 || levels[2][0] != 0 {
+		t.Errorf("got levels: %d %d, want levels: %d %d\n", levels[1][1], levels[2][0], LEVELS-1, 0)
+	}
+}
+
+func TestDisplay_planes(t *testing.T) {
+	palette, _ := ParsePalette("#000000,#ffffff,#ff0000,#00ff00")
+
+	var frame Frame
+	frame[0][0], frame[1][0], frame[2][0] = 0x1, 0x2, 0x3
+
+	display := NewDisplay(Filter{}, palette)
+	display.Update(frame)
+
+	if levels := display.Levels(); levels[0][0] != LEVELS-1 || levels[1][0] != LEVELS || levels[2][0] != LEVELS+2 {
+		t.Errorf("got levels: %d %d %d, want levels: %d %d %d\n", levels[0][0], levels[1][0], levels[2][0], LEVELS-1, LEVELS, LEVELS+2)
+	}
+
+	if got := display.Image(display.Levels(), 2).At(2, 0); got != opaque(palette[3]) {
+		t.Errorf("got color: %v, want color: %v\n", got, opaque(palette[3]))
+	}
+
+	// NOTE: 2 colors draw every plane in the pixel color
+	display.SetPalette(NewPalette(sdl.Color{}, sdl.Color{R: 0xFF, G: 0xFF, B: 0xFF}))
+	display.Update(frame)
+
+	if levels := display.Levels(); levels[1][0] != LEVELS-1 || levels[2][0] != LEVELS-1 {
+		t.Errorf("got levels: %d %d, want levels: %d %d\n", levels[1][0], levels[2][0], LEVELS-1, LEVELS-1)
+	}
+}
+
+func TestDisplay_persistence(t *testing.T) {
+	mock := &MockWindow{}
+	mock.SetPixel(0x00, 0x00)
+
+	display := NewDisplay(Filter{Persistence: 2}, NewPalette(sdl.Color{}, sdl.Color{R: 0xFF, G: 0xFF, B: 0xFF}))
+	display.Update(mock.Frame())
+
+	mock.Clear()
+
+	for _, want := range []byte{16, 0} {
+		display.Update(mock.Frame())
+
+		if got := display.Levels()[0][0]; got != want {
+			t.Errorf("got level: %d, want level: %d\n", got, want)
+		}
+	}
+
+	if frame := display.Frame(); frame[0][0] != 0 {
+		t.Errorf("got frame pixel: %d, want frame pixel: %d\n", frame[0][0], 0)
+	}
+}
+
+func TestDisplay_blend(t *testing.T) {
+	mock := &MockWindow{}
+
+	display := NewDisplay(Filter{Blend: true}, NewPalette(sdl.Color{}, sdl.Color{R: 0xFF, G: 0xFF, B: 0xFF}))
+	display.Update(mock.Frame())
+
+	mock.SetPixel(0x00, 0x00)
+	display.Update(mock.Frame())
+
+	if got := display.Levels()[0][0]; got != 16 {
+		t.Errorf("got level: %d, want level: %d\n", got, 16)
+	}
+}
+
+func TestDisplay_scanlines(t *testing.T) {
+	mock := &MockWindow{}
+	mock.SetPixel(0x00, 0x00)
+
+	display := NewDisplay(Filter{Scanlines: true, Grid: true}, NewPalette(sdl.Color{}, sdl.Color{R: 0xFF, G: 0xFF, B: 0xFF}))
+	display.Update(mock.Frame())
+
+	img := display.Image(display.Levels(), 4)
+
+	tests := []struct {
+		x, y int
+		want byte
+	}{
+		{0, 0, LEVELS - 1},
+		{0, 1, (LEVELS - 1) / 2},
+		{3, 0, (LEVELS - 1) / 2},
+		{0, 3, (LEVELS - 1) / 4},
+		{4, 0, 0},
+	}
+
+	for _, test := range tests {
+		if got := img.ColorIndexAt(test.x, test.y); got != test.want {
+			t.Errorf("got level(%d, %d): %d, want level(%d, %d): %d\n", test.x, test.y, got, test.x, test.y, test.want)
+		}
+	}
+}
@@ -1,31 +1,70 @@
 package screen
 
 import (
+	"log"
+	"sync"
+	"time"
+
 	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
 )
 
 type Chip8Screen interface {
 	SetPixel(x, y byte)
 	GetPixel(x, y byte) byte
 	Clear()
+	Publish()
+	SetHires(hires bool)
+	Hires() bool
+	Frame() Frame
+	SetFrame(frame Frame)
+	Collide(x, y byte)
+	SelectPlanes(planes byte)
+	Planes() byte
 }
 
 type MainWindow struct {
-	window          *sdl.Window
-	renderer        *sdl.Renderer
-	backgroundColor sdl.Color
-	pixelColor      sdl.Color
-	buffer          [64][32]byte
+	Plane
+	window           *sdl.Window
+	renderer         *sdl.Renderer
+	palette          Palette
+	paletteName      string
+	paletteChanged   func(name string)
+	front            Frame
+	frontCollisions  Frame
+	frontLock        sync.Mutex
+	collisions       CollisionOverlay
+	collisionOverlay bool
+	screenshotDir    string
+	screenshotScale  int
+	recorder         Recorder
+	display          *Display
+	displayStart     time.Time
+	displayFrames    int
+	textureScale     int
+	font             *ttf.Font
+	status           string
+	statusUntil      time.Time
+	browser          *Browser
+	texture          *sdl.Texture
+	scaling          string
+	fullscreen       bool
 }
 
-func NewMainWindow(title string, width, height int32, backgroundColor, pixelColor uint64) (*MainWindow, error) {
+func NewMainWindow(title string, width, height int32, palette Palette) (*MainWindow, error) {
 	var mw MainWindow
 
 	if err := sdl.Init(sdl.INIT_VIDEO); err != nil {
 		return nil, err
 	}
 
-	window, err := sdl.CreateWindow(title, sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED, width, height, sdl.WINDOW_SHOWN)
+	// NOTE: The font is only needed for the status overlay and the browser, the emulator runs without it
+	font, err := openFont(14)
+	if err != nil {
+		log.Printf("screen.openFont(): %v, status messages are not shown\n", err)
+	}
+
+	window, err := sdl.CreateWindow(title, sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED, width, height, sdl.WINDOW_SHOWN|sdl.WINDOW_RESIZABLE)
 	if err != nil {
 		return nil, err
 	}
@@ -35,69 +74,293 @@ func NewMainWindow(title string, width, height int32, backgroundColor, pixelColo
 		return nil, err
 	}
 
+	texture, err := renderer.CreateTexture(uint32(sdl.PIXELFORMAT_RGBA32), sdl.TEXTUREACCESS_STREAMING, WIDTH, HEIGHT)
+	if err != nil {
+		return nil, err
+	}
+
 	mw.window = window
 	mw.renderer = renderer
+	mw.texture = texture
+	mw.font = font
+	mw.scaling = SCALING_INTEGER
+	mw.screenshotDir = SCREENSHOT_DIR
+	mw.screenshotScale = SCREENSHOT_SCALE
 
-	mw.backgroundColor = sdl.Color{
-		R: uint8((backgroundColor & 0xFF000000) >> 24),
-		G: uint8((backgroundColor & 0x00FF0000) >> 16),
-		B: uint8((backgroundColor & 0x0000FF00) >> 8),
-		A: uint8(backgroundColor & 0x000000FF)}
-
-	mw.pixelColor = sdl.Color{
-		R: uint8((pixelColor & 0xFF000000) >> 24),
-		G: uint8((pixelColor & 0x00FF0000) >> 16),
-		B: uint8((pixelColor & 0x0000FF00) >> 8),
-		A: uint8(pixelColor & 0x000000FF)}
+	mw.palette = palette
+	mw.display = NewDisplay(Filter{}, palette)
+	mw.displayStart = time.Now()
+	mw.textureScale = 2
 
 	return &mw, nil
 }
 
+func ParseColor(color uint64) sdl.Color {
+	return sdl.Color{
+		R: uint8((color & 0xFF000000) >> 24),
+		G: uint8((color & 0x00FF0000) >> 16),
+		B: uint8((color & 0x0000FF00) >> 8),
+		A: uint8(color & 0x000000FF)}
+}
+
 func (mw *MainWindow) Render() {
-	for i := byte(0); i < 32; i++ {
-		for k := byte(0); k < 64; k++ {
-			if mw.GetPixel(k, i) == 1 {
-				mw.renderer.SetDrawColor(mw.pixelColor.R, mw.pixelColor.G, mw.pixelColor.B, mw.pixelColor.A)
-			} else {
-				mw.renderer.SetDrawColor(mw.backgroundColor.R, mw.backgroundColor.G, mw.backgroundColor.B, mw.backgroundColor.A)
+	if mw.browser != nil && mw.browser.Active() {
+		background := mw.palette.Background()
+		mw.renderer.SetDrawColor(background.R, background.G, background.B, background.A)
+		mw.renderer.Clear()
+		mw.drawBrowser()
+		mw.renderer.Present()
+
+		return
+	}
+
+	mw.updateDisplay()
+	mw.updateTexture()
+
+	width, height, err := mw.renderer.GetOutputSize()
+	if err != nil {
+		return
+	}
+
+	viewport := Viewport(width, height, mw.scaling)
+
+	mw.renderer.SetDrawColor(0x00, 0x00, 0x00, 0xFF)
+	mw.renderer.Clear()
+	mw.renderer.Copy(mw.texture, nil, &viewport)
+
+	if mw.collisionOverlay {
+		mw.drawCollisions(viewport)
+	}
+
+	if mw.font != nil {
+		mw.drawStatus()
+	}
+
+	mw.renderer.Present()
+}
+
+// NOTE: Render runs every delay ms, so the display is updated as many times as the 60Hz clock has ticked since the last call
+func (mw *MainWindow) updateDisplay() {
+	frames := int(time.Since(mw.displayStart) * FPS / time.Second)
+
+	for ; mw.displayFrames < frames; mw.displayFrames++ {
+		mw.display.Update(mw.Front())
+
+		if mw.collisionOverlay {
+			mw.collisions.Update(mw.takeFrontCollisions())
+		}
+
+		if mw.recorder != nil {
+			if err := mw.recorder.Capture(mw.display); err != nil {
+				log.Printf("mw.recorder.Capture(): %v\n", err)
+				mw.recorder = nil
 			}
+		}
+	}
+}
+
+// NOTE: Alpha is forced to 0xFF because the default colors have A == 0 and the texture is blended
+func (mw *MainWindow) updateTexture() {
+	pixels, pitch, err := mw.texture.Lock(nil)
+	if err != nil {
+		return
+	}
+	defer mw.texture.Unlock()
 
-			mw.renderer.FillRect(&sdl.Rect{
-				X: int32(k) * 10,
-				Y: int32(i) * 10,
-				W: 10,
-				H: 10,
-			})
+	img := mw.display.Image(mw.display.Levels(), mw.textureScale)
+
+	for i := 0; i < LORES_HEIGHT*mw.textureScale; i++ {
+		for k := 0; k < LORES_WIDTH*mw.textureScale; k++ {
+			r, g, b, _ := img.At(k, i).RGBA()
+
+			offset := i*pitch + k*4
+			pixels[offset] = uint8(r >> 8)
+			pixels[offset+1] = uint8(g >> 8)
+			pixels[offset+2] = uint8(b >> 8)
+			pixels[offset+3] = 0xFF
 		}
 	}
+}
 
-	mw.renderer.Present()
-	mw.renderer.Clear()
+// NOTE: The texture has one texel per hires pixel, scanlines and the grid need more, so it grows with the filter
+func (mw *MainWindow) SetFilter(filter Filter) error {
+	scale := 2
+	if filter.Spatial() {
+		scale = FILTER_SCALE
+	}
+
+	texture, err := mw.renderer.CreateTexture(uint32(sdl.PIXELFORMAT_RGBA32), sdl.TEXTUREACCESS_STREAMING, int32(LORES_WIDTH*scale), int32(LORES_HEIGHT*scale))
+	if err != nil {
+		return err
+	}
+
+	mw.texture.Destroy()
+	mw.texture = texture
+	mw.textureScale = scale
+	mw.display = NewDisplay(filter, mw.palette)
+
+	return nil
 }
 
-func (mw *MainWindow) Free() {
-	mw.window.Destroy()
-	mw.renderer.Destroy()
+func (mw *MainWindow) SetScaling(mode string) error {
+	if err := ValidScaling(mode); err != nil {
+		return err
+	}
+
+	mw.scaling = mode
+
+	return nil
+}
+
+func (mw *MainWindow) ToggleFullscreen() error {
+	var flags uint32
+	if !mw.fullscreen {
+		flags = sdl.WINDOW_FULLSCREEN_DESKTOP
+	}
+
+	if err := mw.window.SetFullscreen(flags); err != nil {
+		return err
+	}
+
+	mw.fullscreen = !mw.fullscreen
+
+	return nil
+}
+
+func (mw *MainWindow) SetPalette(name string, palette Palette) {
+	mw.palette = palette
+	mw.paletteName = name
+	mw.display.SetPalette(palette)
+}
+
+func (mw *MainWindow) OnPaletteChange(changed func(name string)) {
+	mw.paletteChanged = changed
 }
 
-func (mw *MainWindow) SetPixel(x, y byte) {
-	if x < 64 && y < 32 {
-		mw.buffer[x][y] ^= 1
+func (mw *MainWindow) CyclePalette() error {
+	name := NextPalette(mw.paletteName)
+
+	palette, err := ParsePalette(name)
+	if err != nil {
+		return err
+	}
+
+	mw.SetPalette(name, palette)
+	SendStatus("Palette: "+name, PALETTE_STATUS_DURATION)
+
+	if mw.paletteChanged != nil {
+		mw.paletteChanged(name)
 	}
+
+	return nil
+}
+
+func (mw *MainWindow) SetBrowser(browser *Browser) {
+	mw.browser = browser
 }
 
-func (mw *MainWindow) GetPixel(x, y byte) byte {
-	if x < 64 && y < 32 {
-		return mw.buffer[x][y]
+func (mw *MainWindow) SetTitle(title string) {
+	mw.window.SetTitle(title)
+}
+
+func (mw *MainWindow) SetScreenshotOptions(dir string, scale int) {
+	mw.screenshotDir = dir
+	mw.screenshotScale = scale
+}
+
+func (mw *MainWindow) Screenshot() (string, error) {
+	return Screenshot(mw.screenshotDir, mw.display, mw.screenshotScale)
+}
+
+func openFont(size int) (*ttf.Font, error) {
+	if err := ttf.Init(); err != nil {
+		return nil, err
 	}
 
-	return 0x00
+	return ttf.OpenFont("assets/font.ttf", size)
 }
 
-func (mw *MainWindow) Clear() {
-	for i := 0; i < 32; i++ {
-		for k := 0; k < 64; k++ {
-			mw.buffer[k][i] = 0x00
+func (mw *MainWindow) drawStatus() {
+	for len(Status) > 0 {
+		message := <-Status
+		mw.status = message.Text
+		mw.statusUntil = time.Time{}
+
+		if message.Duration > 0 {
+			mw.statusUntil = time.Now().Add(message.Duration)
 		}
 	}
+
+	if mw.status == "" || (!mw.statusUntil.IsZero() && time.Now().After(mw.statusUntil)) {
+		return
+	}
+
+	mw.drawText(mw.status, 4, 4, false)
+}
+
+func (mw *MainWindow) StartRecording(fname string) error {
+	recorder, err := NewRecorder(fname, mw.screenshotScale)
+	if err != nil {
+		return err
+	}
+
+	mw.recorder = recorder
+
+	return nil
+}
+
+func (mw *MainWindow) StopRecording() error {
+	if mw.recorder == nil {
+		return nil
+	}
+
+	err := mw.recorder.Close()
+	mw.recorder = nil
+
+	return err
+}
+
+func (mw *MainWindow) Recording() bool {
+	return mw.recorder != nil
+}
+
+func (mw *MainWindow) Free() {
+	if err := mw.StopRecording(); err != nil {
+		log.Printf("mw.StopRecording(): %v\n", err)
+	}
+
+	if mw.font != nil {
+		mw.font.Close()
+	}
+	mw.texture.Destroy()
+	mw.window.Destroy()
+	mw.renderer.Destroy()
+}
+
+// NOTE: The virtualmachine draws into the plane and publishes it once per frame, so the renderer never sees a half drawn frame.
+// The collisions of the plane are always taken, so they don't pile up while the overlay is off
+func (mw *MainWindow) Publish() {
+	collisions := mw.TakeCollisions()
+
+	mw.frontLock.Lock()
+	defer mw.frontLock.Unlock()
+
+	mw.front = mw.Frame()
+
+	if !mw.collisionOverlay {
+		return
+	}
+
+	for i := 0; i < HEIGHT; i++ {
+		for k := 0; k < WIDTH; k++ {
+			mw.frontCollisions[k][i] |= collisions[k][i]
+		}
+	}
+}
+
+func (mw *MainWindow) Front() Frame {
+	mw.frontLock.Lock()
+	defer mw.frontLock.Unlock()
+
+	return mw.front
 }
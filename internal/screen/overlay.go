@@ -0,0 +1,71 @@
+package screen
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const COLLISION_FLASH_FRAMES = 30
+const COLLISION_BLINK_FRAMES = 4
+
+// NOTE: Every collided pixel blinks for COLLISION_FLASH_FRAMES frames after the sprite that hit it
+type CollisionOverlay struct {
+	age [WIDTH][HEIGHT]int
+}
+
+func (overlay *CollisionOverlay) Update(collisions Frame) {
+	for i := 0; i < HEIGHT; i++ {
+		for k := 0; k < WIDTH; k++ {
+			if collisions[k][i] == 1 {
+				overlay.age[k][i] = COLLISION_FLASH_FRAMES
+			} else if overlay.age[k][i] > 0 {
+				overlay.age[k][i]--
+			}
+		}
+	}
+}
+
+func (overlay *CollisionOverlay) Visible(x, y int) bool {
+	age := overlay.age[x][y]
+	return age > 0 && (age/COLLISION_BLINK_FRAMES)%2 == 1
+}
+
+func (mw *MainWindow) SetCollisionOverlay(enabled bool) {
+	mw.collisionOverlay = enabled
+}
+
+func (mw *MainWindow) takeFrontCollisions() Frame {
+	mw.frontLock.Lock()
+	defer mw.frontLock.Unlock()
+
+	collisions := mw.frontCollisions
+	mw.frontCollisions = Frame{}
+
+	return collisions
+}
+
+func (mw *MainWindow) drawCollisions(viewport sdl.Rect) {
+	var rects []sdl.Rect
+
+	for i := 0; i < HEIGHT; i++ {
+		for k := 0; k < WIDTH; k++ {
+			if !mw.collisions.Visible(k, i) {
+				continue
+			}
+
+			x, y := viewport.X+int32(k)*viewport.W/WIDTH, viewport.Y+int32(i)*viewport.H/HEIGHT
+			rects = append(rects, sdl.Rect{
+				X: x,
+				Y: y,
+				W: viewport.X + int32(k+1)*viewport.W/WIDTH - x,
+				H: viewport.Y + int32(i+1)*viewport.H/HEIGHT - y,
+			})
+		}
+	}
+
+	if len(rects) == 0 {
+		return
+	}
+
+	mw.renderer.SetDrawColor(0xFF, 0x00, 0x00, 0xFF)
+	mw.renderer.FillRects(rects)
+}
@@ -0,0 +1,35 @@
+package screen
+
+import (
+	"testing"
+)
+
+func TestCollisionOverlay(t *testing.T) {
+	var overlay CollisionOverlay
+	var collisions Frame
+
+	collisions[3][4] = 1
+	overlay.Update(collisions)
+
+	if !overlay.Visible(3, 4) || overlay.Visible(4, 4) {
+		t.Errorf("got visible: %t, %t, want visible: %t, %t\n", overlay.Visible(3, 4), overlay.Visible(4, 4), true, false)
+	}
+
+	blinked := false
+	for i := 1; i < COLLISION_FLASH_FRAMES; i++ {
+		overlay.Update(Frame{})
+
+		if !overlay.Visible(3, 4) {
+			blinked = true
+		}
+	}
+
+	if !blinked {
+		t.Errorf("got blinked: %t, want blinked: %t\n", blinked, true)
+	}
+
+	overlay.Update(Frame{})
+	if overlay.Visible(3, 4) {
+		t.Errorf("got visible after %d frames: %t, want visible: %t\n", COLLISION_FLASH_FRAMES, true, false)
+	}
+}
@@ -0,0 +1,124 @@
+package screen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const PALETTE_DEFAULT = "default"
+const PALETTE_STATUS_DURATION = 2 * time.Second
+
+// NOTE: Entry 0 is the background and entry 1 the pixel color, 4 and 16 entries are indexed by the bitplanes of a pixel
+type Palette []sdl.Color
+
+var PALETTE_NAMES = []string{PALETTE_DEFAULT, "green", "amber", "lcd", "octo"}
+
+var palettes = map[string]string{
+	PALETTE_DEFAULT: "#000000,#FFFFFF",
+	"green":         "#0A140A,#33FF66",
+	"amber":         "#140C00,#FFB000",
+	"lcd":           "#9BBC0F,#0F380F,#306230,#8BAC0F",
+	"octo":          "#996600,#FFCC00,#FF6600,#662200",
+}
+
+func NewPalette(backgroundColor, pixelColor sdl.Color) Palette {
+	return Palette{backgroundColor, pixelColor}
+}
+
+// NOTE: A palette is either a preset name or a comma separated list of 2, 4 or 16 colors
+func ParsePalette(s string) (Palette, error) {
+	if preset, ok := palettes[s]; ok {
+		s = preset
+	}
+
+	fields := strings.Split(s, ",")
+	if len(fields) != 2 && len(fields) != 4 && len(fields) != 16 {
+		return nil, fmt.Errorf("invalid palette %q, want a preset or 2, 4 or 16 colors", s)
+	}
+
+	palette := make(Palette, len(fields))
+
+	for i, field := range fields {
+		color, err := ParseColorString(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+
+		palette[i] = color
+	}
+
+	return palette, nil
+}
+
+// NOTE: Accepts #RRGGBB, #RRGGBBAA and the old uint32 values like 0xFFFFFF00
+func ParseColorString(s string) (sdl.Color, error) {
+	if !strings.HasPrefix(s, "#") {
+		color, err := strconv.ParseUint(s, 0, 32)
+		if err != nil {
+			return sdl.Color{}, fmt.Errorf("invalid color %q", s)
+		}
+
+		return ParseColor(color), nil
+	}
+
+	hex := s[1:]
+	if len(hex) == 6 {
+		hex += "FF"
+	}
+
+	color, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 8 {
+		return sdl.Color{}, fmt.Errorf("invalid color %q", s)
+	}
+
+	return ParseColor(color), nil
+}
+
+func NextPalette(name string) string {
+	for i, preset := range PALETTE_NAMES {
+		if preset == name {
+			return PALETTE_NAMES[(i+1)%len(PALETTE_NAMES)]
+		}
+	}
+
+	return PALETTE_NAMES[0]
+}
+
+func (palette Palette) Background() sdl.Color {
+	return palette[0]
+}
+
+func (palette Palette) Pixel() sdl.Color {
+	return palette[1]
+}
+
+// NOTE: A 2 color palette draws every plane in the pixel color, otherwise the planes of a pixel are its entry
+func (palette Palette) index(pixel byte) int {
+	if len(palette) <= 2 {
+		if pixel != 0 {
+			return 1
+		}
+
+		return 0
+	}
+
+	return int(pixel) & (len(palette) - 1)
+}
+
+func (palette Palette) Equal(other Palette) bool {
+	if len(palette) != len(other) {
+		return false
+	}
+
+	for i := range palette {
+		if palette[i] != other[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,67 @@
+package screen
+
+import (
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestParseColorString(t *testing.T) {
+	tests := []struct {
+		s    string
+		want sdl.Color
+	}{
+		{"#1a1c2c", sdl.Color{R: 0x1A, G: 0x1C, B: 0x2C, A: 0xFF}},
+		{"#1A1C2C80", sdl.Color{R: 0x1A, G: 0x1C, B: 0x2C, A: 0x80}},
+		{"0xFFFFFF00", sdl.Color{R: 0xFF, G: 0xFF, B: 0xFF, A: 0x00}},
+		{"4294967040", sdl.Color{R: 0xFF, G: 0xFF, B: 0xFF, A: 0x00}},
+	}
+
+	for _, test := range tests {
+		got, err := ParseColorString(test.s)
+		if err != nil || got != test.want {
+			t.Errorf("got color(%s): %v, %v, want color(%s): %v\n", test.s, got, err, test.s, test.want)
+		}
+	}
+
+	for _, s := range []string{"#12345", "#GGGGGG", "red", ""} {
+		if _, err := ParseColorString(s); err == nil {
+			t.Errorf("got err(%s): nil, want err\n", s)
+		}
+	}
+}
+
+func TestParsePalette(t *testing.T) {
+	for _, name := range PALETTE_NAMES {
+		if _, err := ParsePalette(name); err != nil {
+			t.Errorf("ParsePalette(%s): %v\n", name, err)
+		}
+	}
+
+	palette, err := ParsePalette("#000000, #ffffff, #ff0000, #00ff00")
+	if err != nil {
+		t.Fatalf("ParsePalette(): %v\n", err)
+	}
+
+	if len(palette) != 4 || palette.Pixel() != (sdl.Color{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}) {
+		t.Errorf("got palette: %v, want 4 colors with a white pixel\n", palette)
+	}
+
+	if _, err := ParsePalette("#000000,#ffffff,#ff0000"); err == nil {
+		t.Errorf("got err: nil, want err for 3 colors\n")
+	}
+}
+
+func TestNextPalette(t *testing.T) {
+	if got := NextPalette(PALETTE_NAMES[len(PALETTE_NAMES)-1]); got != PALETTE_NAMES[0] {
+		t.Errorf("got palette: %s, want palette: %s\n", got, PALETTE_NAMES[0])
+	}
+
+	if got := NextPalette("#000000,#ffffff"); got != PALETTE_NAMES[0] {
+		t.Errorf("got palette: %s, want palette: %s\n", got, PALETTE_NAMES[0])
+	}
+
+	if got := NextPalette("green"); got != "amber" {
+		t.Errorf("got palette: %s, want palette: %s\n", got, "amber")
+	}
+}
@@ -0,0 +1,120 @@
+package screen
+
+const WIDTH = 128
+const HEIGHT = 64
+const LORES_WIDTH = 64
+const LORES_HEIGHT = 32
+
+// NOTE: XO-CHIP draws on up to 4 bitplanes, the bits of a pixel are the planes it is set in and index the palette
+const PLANES = 4
+const PLANE_DEFAULT = 0x01
+const PLANE_ALL = 1<<PLANES - 1
+
+type Frame [WIDTH][HEIGHT]byte
+
+// NOTE: The plane is always stored in hires, a lores pixel covers 2x2 hires pixels like on SCHIP. The pixels are
+// drawn, read and cleared on the selected planes only, the zero value selects the first one like CHIP-8
+type Plane struct {
+	buffer     Frame
+	collisions Frame
+	hires      bool
+	planes     byte
+	selected   bool
+}
+
+func (plane *Plane) SelectPlanes(planes byte) {
+	plane.planes = planes & PLANE_ALL
+	plane.selected = true
+}
+
+func (plane *Plane) Planes() byte {
+	if !plane.selected {
+		return PLANE_DEFAULT
+	}
+
+	return plane.planes
+}
+
+func (plane *Plane) SetPixel(x, y byte) {
+	planes := plane.Planes()
+
+	if !plane.hires {
+		if x < LORES_WIDTH && y < LORES_HEIGHT {
+			for i := 0; i < 4; i++ {
+				plane.buffer[int(x)*2+i%2][int(y)*2+i/2] ^= planes
+			}
+		}
+
+		return
+	}
+
+	if x < WIDTH && y < HEIGHT {
+		plane.buffer[x][y] ^= planes
+	}
+}
+
+func (plane *Plane) GetPixel(x, y byte) byte {
+	if !plane.hires {
+		if x >= LORES_WIDTH || y >= LORES_HEIGHT {
+			return 0x00
+		}
+
+		x, y = x*2, y*2
+	}
+
+	if x < WIDTH && y < HEIGHT && plane.buffer[x][y]&plane.Planes() != 0 {
+		return 0x01
+	}
+
+	return 0x00
+}
+
+// NOTE: Marks a pixel that a sprite turned off, the collisions are kept until TakeCollisions
+func (plane *Plane) Collide(x, y byte) {
+	if !plane.hires {
+		if x < LORES_WIDTH && y < LORES_HEIGHT {
+			for i := 0; i < 4; i++ {
+				plane.collisions[int(x)*2+i%2][int(y)*2+i/2] = 1
+			}
+		}
+
+		return
+	}
+
+	if x < WIDTH && y < HEIGHT {
+		plane.collisions[x][y] = 1
+	}
+}
+
+func (plane *Plane) TakeCollisions() Frame {
+	collisions := plane.collisions
+	plane.collisions = Frame{}
+
+	return collisions
+}
+
+func (plane *Plane) Clear() {
+	planes := plane.Planes()
+
+	for k := range plane.buffer {
+		for i := range plane.buffer[k] {
+			plane.buffer[k][i] &^= planes
+		}
+	}
+}
+
+func (plane *Plane) SetHires(hires bool) {
+	plane.hires = hires
+}
+
+func (plane *Plane) Hires() bool {
+	return plane.hires
+}
+
+func (plane *Plane) Frame() Frame {
+	return plane.buffer
+}
+
+func (plane *Plane) SetFrame(frame Frame) {
+	plane.buffer = frame
+}
@@ -0,0 +1,173 @@
+package screen
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const FPS = 60
+const RECORDING_DIR = "recordings"
+
+type Recorder interface {
+	Capture(display *Display) error
+	Close() error
+}
+
+type GIFRecorder struct {
+	fname     string
+	scale     int
+	filter    Filter
+	frames    []Levels
+	palettes  []Palette
+	durations []int
+}
+
+type SequenceRecorder struct {
+	pattern string
+	scale   int
+	count   int
+}
+
+// NewRecorder picks the output format by extension: "out.gif" is an animated gif,
+// "frames/shot.png" and "frames/shot.pbm" become frames/shot-000000.png, frames/shot-000001.png, ...
+func NewRecorder(fname string, scale int) (Recorder, error) {
+	if scale < 1 {
+		return nil, fmt.Errorf("invalid recording scale: %d", scale)
+	}
+
+	if dir := filepath.Dir(fname); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	ext := strings.ToLower(filepath.Ext(fname))
+
+	switch ext {
+	case ".gif":
+		return &GIFRecorder{
+			fname: fname,
+			scale: scale,
+		}, nil
+	case ".png", ".pbm":
+		return &SequenceRecorder{
+			pattern: strings.TrimSuffix(fname, filepath.Ext(fname)) + "-%06d" + ext,
+			scale:   scale,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported recording format: %q", ext)
+}
+
+func RecordingName(dir string) string {
+	return filepath.Join(dir, fmt.Sprintf("miya-%s.gif", time.Now().Format("20060102-150405.000")))
+}
+
+func (rec *GIFRecorder) Capture(display *Display) error {
+	frame, palette := display.Levels(), display.Palette()
+	rec.filter = display.Filter()
+
+	if last := len(rec.frames) - 1; last >= 0 && rec.frames[last] == frame && rec.palettes[last].Equal(palette) {
+		rec.durations[last]++
+		return nil
+	}
+
+	rec.frames = append(rec.frames, frame)
+	rec.palettes = append(rec.palettes, palette)
+	rec.durations = append(rec.durations, 1)
+
+	return nil
+}
+
+func (rec *GIFRecorder) Close() error {
+	if len(rec.frames) == 0 {
+		return nil
+	}
+
+	animation := gif.GIF{
+		Image: make([]*image.Paletted, len(rec.frames)),
+		Delay: make([]int, len(rec.frames)),
+	}
+
+	// NOTE: GIF delays are in 1/100s, so every frame end is rounded on the 60Hz timeline to avoid drift
+	elapsed := 0
+	for i, frame := range rec.frames {
+		start := (elapsed*100 + FPS/2) / FPS
+		elapsed += rec.durations[i]
+		end := (elapsed*100 + FPS/2) / FPS
+
+		animation.Image[i] = filterImage(frame, rec.filter, rec.palettes[i], rec.scale)
+		animation.Delay[i] = end - start
+	}
+
+	file, err := os.Create(rec.fname)
+	if err != nil {
+		return err
+	}
+
+	if err := gif.EncodeAll(file, &animation); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// NOTE: PBM is a raw 1-bit dump of the frame, so the filters only apply to PNG frames
+func (rec *SequenceRecorder) Capture(display *Display) error {
+	file, err := os.Create(fmt.Sprintf(rec.pattern, rec.count))
+	if err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(rec.pattern, ".pbm") {
+		err = writePBM(file, display.Frame())
+	} else {
+		err = png.Encode(file, display.Image(display.Levels(), rec.scale))
+	}
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	rec.count++
+
+	return file.Close()
+}
+
+func (rec *SequenceRecorder) Close() error {
+	return nil
+}
+
+func writePBM(w io.Writer, frame Frame) error {
+	if _, err := fmt.Fprintf(w, "P1\n%d %d\n", WIDTH, HEIGHT); err != nil {
+		return err
+	}
+
+	for i := 0; i < HEIGHT; i++ {
+		row := make([]byte, 0, 2*WIDTH)
+		for k := 0; k < WIDTH; k++ {
+			pixel := byte('0')
+			if frame[k][i] != 0 {
+				pixel = '1'
+			}
+
+			row = append(row, pixel, ' ')
+		}
+
+		row[len(row)-1] = '\n'
+		if _, err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package screen
+
+import (
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestGIFRecorder(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "out.gif")
+	mock := &MockWindow{}
+
+	display := NewDisplay(Filter{}, NewPalette(sdl.Color{}, sdl.Color{R: 0xFF, G: 0xFF, B: 0xFF}))
+
+	recorder, err := NewRecorder(fname, 1)
+	if err != nil {
+		t.Fatalf("NewRecorder(): %v\n", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		display.Update(mock.Frame())
+		recorder.Capture(display)
+	}
+
+	mock.SetPixel(0x00, 0x00)
+	display.Update(mock.Frame())
+	recorder.Capture(display)
+
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("recorder.Close(): %v\n", err)
+	}
+
+	file, err := os.Open(fname)
+	if err != nil {
+		t.Fatalf("os.Open(): %v\n", err)
+	}
+	defer file.Close()
+
+	animation, err := gif.DecodeAll(file)
+	if err != nil {
+		t.Fatalf("gif.DecodeAll(): %v\n", err)
+	}
+
+	if len(animation.Image) != 2 {
+		t.Fatalf("got frames: %d, want frames: %d\n", len(animation.Image), 2)
+	}
+
+	// 3 frames at 60Hz = 5/100s, 4 frames = 7/100s
+	if animation.Delay[0] != 5 || animation.Delay[1] != 2 {
+		t.Errorf("got delays: %v, want delays: %v\n", animation.Delay, []int{5, 2})
+	}
+}
+
+func TestSequenceRecorder(t *testing.T) {
+	dir := t.TempDir()
+	mock := &MockWindow{}
+
+	display := NewDisplay(Filter{}, NewPalette(sdl.Color{}, sdl.Color{}))
+
+	recorder, err := NewRecorder(filepath.Join(dir, "frame.pbm"), 1)
+	if err != nil {
+		t.Fatalf("NewRecorder(): %v\n", err)
+	}
+
+	display.Update(mock.Frame())
+	recorder.Capture(display)
+	recorder.Capture(display)
+	recorder.Close()
+
+	for _, fname := range []string{"frame-000000.pbm", "frame-000001.pbm"} {
+		if _, err := os.Stat(filepath.Join(dir, fname)); err != nil {
+			t.Errorf("os.Stat(%s): %v\n", fname, err)
+		}
+	}
+}
+
+func TestNewRecorder_format(t *testing.T) {
+	if _, err := NewRecorder(filepath.Join(t.TempDir(), "out.mp4"), 1); err == nil {
+		t.Errorf("got err: nil, want err for .mp4\n")
+	}
+}
@@ -0,0 +1,38 @@
+package screen
+
+import (
+	"fmt"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const SCALING_INTEGER = "integer"
+const SCALING_FIT = "fit"
+
+func ValidScaling(mode string) error {
+	if mode != SCALING_INTEGER && mode != SCALING_FIT {
+		return fmt.Errorf("unknown scaling: %q, want %s or %s", mode, SCALING_INTEGER, SCALING_FIT)
+	}
+
+	return nil
+}
+
+// Viewport returns the largest 2:1 area centered in the output, the rest of the output is letterboxed
+func Viewport(width, height int32, mode string) sdl.Rect {
+	w, h := width, width/2
+	if h > height {
+		w, h = height*2, height
+	}
+
+	if mode == SCALING_INTEGER && w >= 64 {
+		scale := w / 64
+		w, h = 64*scale, 32*scale
+	}
+
+	return sdl.Rect{
+		X: (width - w) / 2,
+		Y: (height - h) / 2,
+		W: w,
+		H: h,
+	}
+}
@@ -0,0 +1,30 @@
+package screen
+
+import (
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestViewport(t *testing.T) {
+	cases := []struct {
+		width  int32
+		height int32
+		mode   string
+		rect   sdl.Rect
+	}{
+		{640, 320, SCALING_INTEGER, sdl.Rect{X: 0, Y: 0, W: 640, H: 320}},
+		{700, 320, SCALING_INTEGER, sdl.Rect{X: 30, Y: 0, W: 640, H: 320}},
+		{1920, 1080, SCALING_INTEGER, sdl.Rect{X: 0, Y: 60, W: 1920, H: 960}},
+		{1000, 1000, SCALING_INTEGER, sdl.Rect{X: 20, Y: 260, W: 960, H: 480}},
+		{1000, 1000, SCALING_FIT, sdl.Rect{X: 0, Y: 250, W: 1000, H: 500}},
+		{800, 300, SCALING_FIT, sdl.Rect{X: 100, Y: 0, W: 600, H: 300}},
+		{30, 30, SCALING_INTEGER, sdl.Rect{X: 0, Y: 7, W: 30, H: 15}},
+	}
+
+	for _, c := range cases {
+		if rect := Viewport(c.width, c.height, c.mode); rect != c.rect {
+			t.Errorf("got Viewport(%d, %d, %s): %v, want %v\n", c.width, c.height, c.mode, rect, c.rect)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package screen
 
 import (
+	"log"
+	"miya/internal/memory"
 	"os"
 	"time"
 
@@ -17,14 +19,66 @@ type KeyEvent struct {
 	Etype   uint32
 }
 
+type Command int
+
+const (
+	CMD_PAUSE Command = iota
+	CMD_SOFT_RESET
+	CMD_HARD_RESET
+	CMD_FAST_FORWARD
+	CMD_SPEED_DOWN
+	CMD_SPEED_UP
+	CMD_FRAME_ADVANCE
+	CMD_SAVE_STATE
+	CMD_LOAD_STATE
+)
+
+type StatusMessage struct {
+	Text     string
+	Duration time.Duration
+}
+
 var KeyPressed chan KeyEvent
 var Debug chan string
 var Next chan struct{}
+var Commands chan Command
+var Status chan StatusMessage
+var Heatmap chan memory.Heatmap
+
+var exitHooks []func()
+
+var commandKeys = map[sdl.Keycode]Command{
+	sdl.K_F1:  CMD_PAUSE,
+	sdl.K_F2:  CMD_SOFT_RESET,
+	sdl.K_F3:  CMD_HARD_RESET,
+	sdl.K_F4:  CMD_FAST_FORWARD,
+	sdl.K_F5:  CMD_SPEED_DOWN,
+	sdl.K_F6:  CMD_SPEED_UP,
+	sdl.K_F7:  CMD_FRAME_ADVANCE,
+	sdl.K_F8:  CMD_SAVE_STATE,
+	sdl.K_F10: CMD_LOAD_STATE,
+}
 
 func init() {
 	KeyPressed = make(chan KeyEvent)
 	Debug = make(chan string)
 	Next = make(chan struct{})
+	Commands = make(chan Command, 0x10)
+	Status = make(chan StatusMessage, 0x10)
+	Heatmap = make(chan memory.Heatmap, 1)
+}
+
+// NOTE: ShowWindows ends the process, so everything that has to be saved at the end runs here
+func OnExit(hook func()) {
+	exitHooks = append(exitHooks, hook)
+}
+
+// NOTE: Nobody reads the status in headless mode, so the message is dropped instead of blocking the virtualmachine
+func SendStatus(text string, duration time.Duration) {
+	select {
+	case Status <- StatusMessage{text, duration}:
+	default:
+	}
 }
 
 func ShowWindows(delay uint64, windows ...Window) {
@@ -35,6 +89,10 @@ func ShowWindows(delay uint64, windows ...Window) {
 			window.Free()
 		}
 
+		for _, hook := range exitHooks {
+			hook()
+		}
+
 		sdl.Quit()
 		os.Exit(0)
 	}()
@@ -50,13 +108,47 @@ func ShowWindows(delay uint64, windows ...Window) {
 				quit = true
 			case *sdl.MouseButtonEvent:
 				// NOTE: We assume that if WindowID == 2, we are in debug mode
-				if evt.WindowID == 2 && (evt.X >= DEBUG_BUTTON_X && evt.X <= (DEBUG_BUTTON_X+DEBUG_BUTTON_W)) && (evt.Y >= DEBUG_BUTTON_Y && evt.Y <= (DEBUG_BUTTON_Y+DEBUG_BUTTON_H)) {
-					Next <- struct{}{}
+				if dw := findDebugWindow(windows); evt.WindowID == 2 && dw != nil && dw.nextClicked(evt.X, evt.Y) {
+					// NOTE: A click while the virtualmachine is paused or between frames is dropped, the window must not wait for it
+					select {
+					case Next <- struct{}{}:
+					default:
+					}
+				}
+			case *sdl.ControllerDeviceEvent:
+				if evt.Type == sdl.CONTROLLERDEVICEADDED {
+					sdl.GameControllerOpen(int(evt.Which))
+				}
+			case *sdl.ControllerButtonEvent:
+				if browser := findBrowser(windows); browser != nil && evt.State == sdl.PRESSED {
+					browser.button(evt.Button)
 				}
 			case *sdl.KeyboardEvent:
-				KeyPressed <- KeyEvent{
+				// NOTE: Without a running virtualmachine nobody reads KeyPressed, so the browser takes every key
+				if browser := findBrowser(windows); browser != nil && browser.Active() {
+					if evt.Type == sdl.KEYDOWN {
+						browser.key(evt.Keysym.Sym)
+					}
+
+					continue
+				}
+
+				// NOTE: A held key repeats, the repeats are dropped so hotkeys fire once per press and the keypad only gets presses and releases
+				if evt.Repeat != 0 {
+					continue
+				}
+
+				if evt.Type == sdl.KEYDOWN && hotkey(evt, windows) {
+					continue
+				}
+
+				// NOTE: The window never waits for the virtualmachine, a key is dropped while it can't take it
+				select {
+				case KeyPressed <- KeyEvent{
 					Keycode: evt.Keysym.Sym,
 					Etype:   evt.Type,
+				}:
+				default:
 				}
 			}
 
@@ -69,3 +161,121 @@ func ShowWindows(delay uint64, windows ...Window) {
 		time.Sleep(time.Millisecond * time.Duration(delay))
 	}
 }
+
+func findDebugWindow(windows []Window) *DebugWindow {
+	for _, window := range windows {
+		if dw, ok := window.(*DebugWindow); ok {
+			return dw
+		}
+	}
+
+	return nil
+}
+
+func findBrowser(windows []Window) *Browser {
+	for _, window := range windows {
+		if mw, ok := window.(*MainWindow); ok && mw.browser != nil {
+			return mw.browser
+		}
+	}
+
+	return nil
+}
+
+func hotkey(evt *sdl.KeyboardEvent, windows []Window) bool {
+	keycode := evt.Keysym.Sym
+
+	if browser := findBrowser(windows); browser != nil && keycode == sdl.K_ESCAPE {
+		browser.Back()
+		return true
+	}
+
+	if command, ok := commandKeys[keycode]; ok {
+		select {
+		case Commands <- command:
+		default:
+		}
+
+		return true
+	}
+
+	switch keycode {
+	case sdl.K_F12:
+		for _, window := range windows {
+			if mw, ok := window.(*MainWindow); ok {
+				fname, err := mw.Screenshot()
+				if err != nil {
+					log.Printf("mw.Screenshot(): %v\n", err)
+					continue
+				}
+
+				log.Printf("Screenshot saved to %s\n", fname)
+			}
+		}
+	case sdl.K_F11:
+		for _, window := range windows {
+			if mw, ok := window.(*MainWindow); ok {
+				if err := mw.ToggleFullscreen(); err != nil {
+					log.Printf("mw.ToggleFullscreen(): %v\n", err)
+				}
+			}
+		}
+	case sdl.K_p:
+		for _, window := range windows {
+			if mw, ok := window.(*MainWindow); ok {
+				if err := mw.CyclePalette(); err != nil {
+					log.Printf("mw.CyclePalette(): %v\n", err)
+				}
+			}
+		}
+	case sdl.K_F9:
+		for _, window := range windows {
+			if mw, ok := window.(*MainWindow); ok {
+				toggleRecording(mw)
+			}
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+func toggleRecording(mw *MainWindow) {
+	if mw.Recording() {
+		if err := mw.StopRecording(); err != nil {
+			log.Printf("mw.StopRecording(): %v\n", err)
+			return
+		}
+
+		log.Printf("Recording stopped\n")
+		return
+	}
+
+	fname := RecordingName(RECORDING_DIR)
+	if err := mw.StartRecording(fname); err != nil {
+		log.Printf("mw.StartRecording(): %v\n", err)
+		return
+	}
+
+	log.Printf("Recording to %s\n", fname)
+}
+
+func RunHeadless(frames int, runFrame func(), s Chip8Screen, display *Display, recorder Recorder) error {
+	for i := 0; i < frames; i++ {
+		runFrame()
+		display.Update(s.Frame())
+
+		if recorder != nil {
+			if err := recorder.Capture(display); err != nil {
+				return err
+			}
+		}
+	}
+
+	if recorder != nil {
+		return recorder.Close()
+	}
+
+	return nil
+}
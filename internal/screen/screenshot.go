@@ -0,0 +1,48 @@
+package screen
+
+import (
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const SCREENSHOT_DIR = "screenshots"
+const SCREENSHOT_SCALE = 10
+
+func WritePNG(w io.Writer, display *Display, scale int) error {
+	if scale < 1 {
+		return fmt.Errorf("invalid screenshot scale: %d", scale)
+	}
+
+	return png.Encode(w, display.Image(display.Levels(), scale))
+}
+
+func Screenshot(dir string, display *Display, scale int) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	fname := filepath.Join(dir, fmt.Sprintf("miya-%s.png", time.Now().Format("20060102-150405.000")))
+	file, err := os.Create(fname)
+	if err != nil {
+		return "", err
+	}
+
+	if err := WritePNG(file, display, scale); err != nil {
+		file.Close()
+		return "", err
+	}
+
+	return fname, file.Close()
+}
+
+// NOTE: The renderer ignores alpha, so colors like the default 0xFFFFFF00 would be transparent in the image
+func opaque(c sdl.Color) color.RGBA {
+	return color.RGBA{R: c.R, G: c.G, B: c.B, A: 0xFF}
+}
@@ -0,0 +1,51 @@
+package screen
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestWritePNG(t *testing.T) {
+	var buffer bytes.Buffer
+
+	mock := &MockWindow{}
+	mock.SetPixel(0x01, 0x02)
+
+	display := NewDisplay(Filter{}, NewPalette(sdl.Color{R: 0x10, G: 0x20, B: 0x30, A: 0x00}, sdl.Color{R: 0xFF, G: 0xFF, B: 0xFF, A: 0x00}))
+	display.Update(mock.Frame())
+
+	if err := WritePNG(&buffer, display, 2); err != nil {
+		t.Fatalf("WritePNG(): %v\n", err)
+	}
+
+	img, err := png.Decode(&buffer)
+	if err != nil {
+		t.Fatalf("png.Decode(): %v\n", err)
+	}
+
+	if img.Bounds().Dx() != 128 || img.Bounds().Dy() != 64 {
+		t.Errorf("got size: %dx%d, want size: %dx%d\n", img.Bounds().Dx(), img.Bounds().Dy(), 128, 64)
+	}
+
+	pixel := color.RGBAModel.Convert(img.At(3, 5)).(color.RGBA)
+	if pixel != (color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}) {
+		t.Errorf("got pixel(3, 5): %v, want pixel(3, 5): %v\n", pixel, color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF})
+	}
+
+	background := color.RGBAModel.Convert(img.At(0, 0)).(color.RGBA)
+	if background != (color.RGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xFF}) {
+		t.Errorf("got pixel(0, 0): %v, want pixel(0, 0): %v\n", background, color.RGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xFF})
+	}
+}
+
+func TestWritePNG_scale(t *testing.T) {
+	var buffer bytes.Buffer
+
+	if err := WritePNG(&buffer, NewDisplay(Filter{}, NewPalette(sdl.Color{}, sdl.Color{})), 0); err == nil {
+		t.Errorf("got err: nil, want err for scale 0\n")
+	}
+}
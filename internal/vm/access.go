@@ -0,0 +1,37 @@
+package vm
+
+import (
+	"log"
+	"miya/internal/memory"
+)
+
+func (vm *VirtualMachine) SetAccessMap(access *memory.AccessMap) {
+	vm.access = access
+}
+
+func (vm *VirtualMachine) SetWarnSMC(warn bool) {
+	vm.warnSMC = warn
+}
+
+func (vm *VirtualMachine) execute(pc uint16) {
+	if vm.access.Execute(pc) && vm.warnSMC {
+		log.Printf("self-modifying code: PC 0x%03x entered bytes written at runtime, frame %d\n", pc, vm.frame)
+	}
+}
+
+// NOTE: Data reads and writes of the instructions go through here, so the access map sees every use of I
+func (vm *VirtualMachine) read(addr uint16) byte {
+	if vm.access != nil {
+		vm.access.Read(addr)
+	}
+
+	return vm.memory.Read(addr)
+}
+
+func (vm *VirtualMachine) write(addr uint16, data byte) {
+	if vm.access != nil {
+		vm.access.Write(addr)
+	}
+
+	vm.memory.Write(addr, data)
+}
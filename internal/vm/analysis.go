@@ -0,0 +1,369 @@
+package vm
+
+import (
+	"sort"
+)
+
+const ROM_ADDRESS = 0x200
+
+const PLATFORM_CHIP8 = "chip-8"
+const PLATFORM_SCHIP = "schip"
+const PLATFORM_XOCHIP = "xo-chip"
+
+const (
+	EDGE_NEXT     = "next"
+	EDGE_JUMP     = "jump"
+	EDGE_CALL     = "call"
+	EDGE_SKIP     = "skip"
+	EDGE_COMPUTED = "computed"
+)
+
+type Edge struct {
+	From uint16 `json:"from"`
+	To   uint16 `json:"to"`
+	Kind string `json:"kind"`
+}
+
+type Region struct {
+	Start uint16
+	End   uint16
+}
+
+type Quirk struct {
+	Name    string
+	Address uint16
+	Reason  string
+}
+
+type Write struct {
+	Address uint16
+	Target  uint16
+}
+
+type Analysis struct {
+	Size       int
+	Platform   string
+	Quirks     []Quirk
+	Code       []Region
+	Data       []Region
+	Unused     []Region
+	Writes     []Write
+	Unresolved []uint16
+}
+
+// Program is the result of a control-flow-following disassembly, starting at the load address and
+// following jumps, calls and skips. Bytes never reached are left out of instructions
+type Program struct {
+	rom          []byte
+	load         uint16
+	instructions map[uint16]opcode
+	references   map[uint16]bool
+	code         []bool
+}
+
+func Disassemble(rom []byte, load uint16) *Program {
+	program := Program{
+		rom:          rom,
+		load:         load,
+		instructions: make(map[uint16]opcode),
+		references:   make(map[uint16]bool),
+	}
+
+	queue := []uint16{load}
+
+	for len(queue) > 0 {
+		address := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		if _, ok := program.instructions[address]; ok || !program.contains(address, 2) {
+			continue
+		}
+
+		op := program.decode(address)
+		program.instructions[address] = op
+
+		if op.t() == LD_I {
+			program.references[op.nnn()] = true
+		}
+
+		if op.value() == 0xF000 && program.contains(address, 4) {
+			program.references[program.decode(address+2).value()] = true
+		}
+
+		for _, edge := range program.Edges(address) {
+			if edge.Kind != EDGE_COMPUTED {
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+
+	program.code = make([]bool, len(rom))
+	for address := range program.instructions {
+		for i := uint16(0); i < program.size(address); i++ {
+			if program.contains(address+i, 1) {
+				program.code[address+i-program.load] = true
+			}
+		}
+	}
+
+	return &program
+}
+
+func (program *Program) IsCode(address uint16) bool {
+	return program.contains(address, 1) && program.code[address-program.load]
+}
+
+func (program *Program) Load() uint16 {
+	return program.load
+}
+
+func (program *Program) contains(address, size uint16) bool {
+	return address >= program.load && int(address-program.load)+int(size) <= len(program.rom)
+}
+
+func (program *Program) decode(address uint16) opcode {
+	offset := address - program.load
+	return newOpcode(uint16(program.rom[offset])<<8 | uint16(program.rom[offset+1]))
+}
+
+// NOTE: F000 NNNN of XO-CHIP is the only 4 byte instruction, skips jump over all of it
+func (program *Program) size(address uint16) uint16 {
+	if program.contains(address, 2) && program.decode(address).value() == 0xF000 {
+		return 4
+	}
+
+	return 2
+}
+
+func (program *Program) Addresses() []uint16 {
+	addresses := make([]uint16, 0, len(program.instructions))
+	for address := range program.instructions {
+		addresses = append(addresses, address)
+	}
+
+	sort.Slice(addresses, func(i, k int) bool {
+		return addresses[i] < addresses[k]
+	})
+
+	return addresses
+}
+
+func (program *Program) Opcode(address uint16) (uint16, bool) {
+	op, ok := program.instructions[address]
+	return op.value(), ok
+}
+
+func (program *Program) Edges(address uint16) []Edge {
+	op := program.decode(address)
+	next := address + program.size(address)
+
+	switch {
+	case op.value() == 0x00EE || op.value() == 0x00FD:
+		return nil
+	case op.t() == JP:
+		return []Edge{{address, op.nnn(), EDGE_JUMP}}
+	case op.t() == CALL:
+		return []Edge{{address, op.nnn(), EDGE_CALL}, {address, next, EDGE_NEXT}}
+	case op.t() == JP_V0:
+		return []Edge{{address, op.nnn(), EDGE_COMPUTED}}
+	case skips(op):
+		return []Edge{{address, next, EDGE_NEXT}, {address, next + program.size(next), EDGE_SKIP}}
+	}
+
+	return []Edge{{address, next, EDGE_NEXT}}
+}
+
+func skips(op opcode) bool {
+	return op.t() == SE_VX || op.t() == SNE || op.t() == SE_VX_VY && op.n() == 0 || op.t() == SNE_VX_VY || op.t() == SKP && (op.nn() == 0x9E || op.nn() == 0xA1)
+}
+
+func Analyze(rom []byte, load uint16) *Analysis {
+	program := Disassemble(rom, load)
+	cfg := BuildCFG(program)
+	analysis := Analysis{
+		Size:     len(rom),
+		Platform: PLATFORM_CHIP8,
+	}
+
+	for _, address := range program.Addresses() {
+		op := program.instructions[address]
+
+		switch platform(op) {
+		case PLATFORM_XOCHIP:
+			analysis.Platform = PLATFORM_XOCHIP
+		case PLATFORM_SCHIP:
+			if analysis.Platform == PLATFORM_CHIP8 {
+				analysis.Platform = PLATFORM_SCHIP
+			}
+		}
+
+		if quirk, ok := quirk(cfg, address, op); ok {
+			quirk.Address = address
+			analysis.Quirks = append(analysis.Quirks, quirk)
+		}
+
+		if op.t() == JP_V0 {
+			analysis.Unresolved = append(analysis.Unresolved, address)
+		}
+
+		if op.t() == LDF && (op.nn() == 0x33 || op.nn() == 0x55) {
+			if target, ok := program.lastI(address); ok && program.writesCode(target, op) {
+				analysis.Writes = append(analysis.Writes, Write{address, target})
+			}
+		}
+	}
+
+	analysis.Code, analysis.Data, analysis.Unused = program.regions()
+
+	return &analysis
+}
+
+func platform(op opcode) string {
+	switch {
+	case op.value() == 0xF000, op.t() == SE_VX_VY && (op.n() == 0x2 || op.n() == 0x3), op.t() == CLC && op.nnn()&0xFF0 == 0x0D0:
+		return PLATFORM_XOCHIP
+	case op.t() == LDF && (op.nn() == 0x01 && op.x() != 0 || op.value() == 0xF002 || op.nn() == 0x3A):
+		return PLATFORM_XOCHIP
+	case op.value() == 0x00FE, op.value() == 0x00FF, op.value() == 0x00FD, op.value() == 0x00FB, op.value() == 0x00FC:
+		return PLATFORM_SCHIP
+	case op.t() == CLC && op.nnn()&0xFF0 == 0x0C0, op.t() == DRW && op.n() == 0:
+		return PLATFORM_SCHIP
+	case op.t() == LDF && (op.nn() == 0x30 || op.nn() == 0x75 || op.nn() == 0x85):
+		return PLATFORM_SCHIP
+	}
+
+	return PLATFORM_CHIP8
+}
+
+// NOTE: These are the instructions whose result differs between the VIP and SCHIP interpreters, checked against
+// the code around them, a shift of VX right after 8XY0 or a VF or I that is overwritten before it is read give the same run on both
+func quirk(cfg *CFG, address uint16, op opcode) (Quirk, bool) {
+	switch {
+	case op.t() == VX_VY && (op.n() == 0x6 || op.n() == 0xE) && op.x() != op.y() && !cfg.copied(address, op):
+		return Quirk{Name: "shift", Reason: "8XY6/8XYE with X != Y and VX not set from VY before, the VIP shifts VY and SCHIP shifts VX"}, true
+	case op.t() == VX_VY && op.n() >= 0x1 && op.n() <= 0x3 && cfg.reaches(address, readsVF, writesVF):
+		return Quirk{Name: "vf reset", Reason: "8XY1/8XY2/8XY3 followed by a read of VF, the VIP resets VF"}, true
+	case op.t() == JP_V0 && op.x() != 0:
+		return Quirk{Name: "jump", Reason: "BNNN, the VIP jumps to NNN + V0 and SCHIP to XNN + VX"}, true
+	case op.t() == LDF && (op.nn() == 0x55 || op.nn() == 0x65) && cfg.reaches(address, usesI, setsI):
+		return Quirk{Name: "memory", Reason: "FX55/FX65 followed by a use of I without a new ANNN, the VIP increments I and SCHIP leaves it"}, true
+	}
+
+	return Quirk{}, false
+}
+
+func readsVF(op opcode) bool {
+	switch op.t() {
+	case SE_VX, SNE, ADD, SKP, JP_V0:
+		return op.x() == 0xF
+	case SE_VX_VY, SNE_VX_VY, DRW:
+		return op.x() == 0xF || op.y() == 0xF
+	case VX_VY:
+		return op.y() == 0xF || op.x() == 0xF && op.n() != 0x0
+	case LDF:
+		return op.x() == 0xF && (op.nn() == 0x15 || op.nn() == 0x18 || op.nn() == 0x1E || op.nn() == 0x29 || op.nn() == 0x30 || op.nn() == 0x33 || op.nn() == 0x55 || op.nn() == 0x75)
+	}
+
+	return false
+}
+
+func writesVF(op opcode) bool {
+	switch op.t() {
+	case LD_VX, RND:
+		return op.x() == 0xF
+	case VX_VY:
+		return op.x() == 0xF || op.n() >= 0x4 && op.n() <= 0x7 || op.n() == 0xE
+	case DRW:
+		return true
+	case LDF:
+		return op.x() == 0xF && (op.nn() == 0x07 || op.nn() == 0x0A || op.nn() == 0x65 || op.nn() == 0x85)
+	}
+
+	return false
+}
+
+func usesI(op opcode) bool {
+	return op.t() == DRW || op.t() == LDF && (op.nn() == 0x1E || op.nn() == 0x33 || op.nn() == 0x55 || op.nn() == 0x65)
+}
+
+func setsI(op opcode) bool {
+	return op.t() == LD_I || op.value() == 0xF000 || op.t() == LDF && (op.nn() == 0x29 || op.nn() == 0x30)
+}
+
+// NOTE: Walks back over the straight line code before address for the ANNN that set I, FX1E makes I unknown
+func (program *Program) lastI(address uint16) (uint16, bool) {
+	for i := 0; i < 0x10 && address > program.load; i++ {
+		address -= 2
+
+		op, ok := program.instructions[address]
+		if !ok || op.t() == JP || op.t() == JP_V0 || op.value() == 0x00EE {
+			return 0, false
+		}
+
+		if op.t() == LDF && op.nn() == 0x1E {
+			return 0, false
+		}
+
+		if op.t() == LD_I {
+			return op.nnn(), true
+		}
+	}
+
+	return 0, false
+}
+
+func (program *Program) writesCode(target uint16, op opcode) bool {
+	size := uint16(op.x()) + 1
+	if op.nn() == 0x33 {
+		size = 3
+	}
+
+	for address := target; address < target+size; address++ {
+		if program.IsCode(address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NOTE: Bytes that were never reached are data when an ANNN points into them, otherwise they are unused
+func (program *Program) regions() ([]Region, []Region, []Region) {
+	var code, data, unused []Region
+
+	for start := 0; start < len(program.code); {
+		end := start
+		for end+1 < len(program.code) && program.code[end+1] == program.code[start] {
+			end++
+		}
+
+		region := Region{uint16(start) + program.load, uint16(end) + program.load}
+
+		switch {
+		case program.code[start]:
+			code = append(code, region)
+		case program.referenced(region):
+			data = append(data, region)
+		default:
+			unused = append(unused, region)
+		}
+
+		start = end + 1
+	}
+
+	return code, data, unused
+}
+
+func (program *Program) referenced(region Region) bool {
+	for address := range program.references {
+		if address >= region.Start && address <= region.End {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (region Region) Size() int {
+	return int(region.End-region.Start) + 1
+}
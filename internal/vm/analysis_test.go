@@ -0,0 +1,118 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	rom := []byte{
+		0x00, 0xFF, // 0x200 HIGH
+		0xA2, 0x0E, // 0x202 LD I, 0x20E
+		0x30, 0x01, // 0x204 SE V0, 0x01
+		0x22, 0x0C, // 0x206 CALL 0x20C
+		0x81, 0x26, // 0x208 SHR V1, V2
+		0x12, 0x08, // 0x20A JP 0x208
+		0x00, 0xEE, // 0x20C RET
+		0xFF, 0x81, // 0x20E data
+		0x00, 0x00, // 0x210 unused
+	}
+
+	analysis := Analyze(rom, ROM_ADDRESS)
+
+	if analysis.Size != len(rom) || analysis.Platform != PLATFORM_SCHIP {
+		t.Errorf("got size: %d, platform: %s, want size: %d, platform: %s\n", analysis.Size, analysis.Platform, len(rom), PLATFORM_SCHIP)
+	}
+
+	if len(analysis.Code) != 1 || analysis.Code[0] != (Region{0x200, 0x20D}) {
+		t.Errorf("got code: %v, want code: %v\n", analysis.Code, []Region{{0x200, 0x20D}})
+	}
+
+	if len(analysis.Data) != 1 || analysis.Data[0] != (Region{0x20E, 0x211}) {
+		t.Errorf("got data: %v, want data: %v\n", analysis.Data, []Region{{0x20E, 0x211}})
+	}
+
+	if len(analysis.Quirks) != 1 || analysis.Quirks[0].Name != "shift" || analysis.Quirks[0].Address != 0x208 {
+		t.Errorf("got quirks: %v, want the shift quirk at 0x208\n", analysis.Quirks)
+	}
+}
+
+func TestAnalyze_quirks(t *testing.T) {
+	rom := []byte{
+		0x81, 0x20, // 0x200 LD V1, V2
+		0x81, 0x26, // 0x202 SHR V1, V2, same on both after the copy
+		0x81, 0x21, // 0x204 OR V1, V2, VF is read next
+		0x3F, 0x00, // 0x206 SE VF, 0x00
+		0x82, 0x32, // 0x208 AND V2, V3, VF is overwritten next
+		0x6F, 0x00, // 0x20A LD VF, 0x00
+		0xF2, 0x55, // 0x20C LD [I], V2, I is used next
+		0xF2, 0x65, // 0x20E LD V2, [I], I is set again next
+		0xA3, 0x00, // 0x210 LD I, 0x300
+		0xD0, 0x15, // 0x212 DRW V0, V1, 5
+		0x12, 0x14, // 0x214 JP 0x214
+	}
+
+	analysis := Analyze(rom, ROM_ADDRESS)
+
+	want := []Quirk{{Name: "vf reset", Address: 0x204}, {Name: "memory", Address: 0x20C}}
+	if len(analysis.Quirks) != len(want) {
+		t.Fatalf("got quirks: %v, want quirks: %v\n", analysis.Quirks, want)
+	}
+
+	for i, quirk := range analysis.Quirks {
+		if quirk.Name != want[i].Name || quirk.Address != want[i].Address {
+			t.Errorf("got quirk: %s at 0x%03x, want quirk: %s at 0x%03x\n", quirk.Name, quirk.Address, want[i].Name, want[i].Address)
+		}
+	}
+}
+
+func TestAnalyze_unused(t *testing.T) {
+	rom := []byte{
+		0x12, 0x04, // 0x200 JP 0x204
+		0x60, 0x01, // 0x202 unused
+		0xB3, 0x00, // 0x204 JP V0, 0x300
+	}
+
+	analysis := Analyze(rom, ROM_ADDRESS)
+
+	if len(analysis.Unused) != 1 || analysis.Unused[0] != (Region{0x202, 0x203}) {
+		t.Errorf("got unused: %v, want unused: %v\n", analysis.Unused, []Region{{0x202, 0x203}})
+	}
+
+	if len(analysis.Unresolved) != 1 || analysis.Unresolved[0] != 0x204 {
+		t.Errorf("got unresolved: %v, want unresolved: %v\n", analysis.Unresolved, []uint16{0x204})
+	}
+}
+
+func TestAnalyze_selfModifying(t *testing.T) {
+	rom := []byte{
+		0xA2, 0x08, // 0x200 LD I, 0x208
+		0x60, 0x01, // 0x202 LD V0, 0x01
+		0xF0, 0x55, // 0x204 LD [I], V0
+		0x12, 0x08, // 0x206 JP 0x208
+		0x12, 0x08, // 0x208 JP 0x208
+	}
+
+	analysis := Analyze(rom, ROM_ADDRESS)
+
+	if len(analysis.Writes) != 1 || analysis.Writes[0] != (Write{0x204, 0x208}) {
+		t.Errorf("got writes: %v, want writes: %v\n", analysis.Writes, []Write{{0x204, 0x208}})
+	}
+}
+
+func TestAnalyze_loadAddress(t *testing.T) {
+	rom := []byte{
+		0xA6, 0x04, // 0x600 LD I, 0x604
+		0x16, 0x02, // 0x602 JP 0x602
+		0xFF, 0x81, // 0x604 data
+	}
+
+	analysis := Analyze(rom, 0x600)
+
+	if len(analysis.Code) != 1 || analysis.Code[0] != (Region{0x600, 0x603}) {
+		t.Errorf("got code: %v, want code: %v\n", analysis.Code, []Region{{0x600, 0x603}})
+	}
+
+	if len(analysis.Data) != 1 || analysis.Data[0] != (Region{0x604, 0x605}) {
+		t.Errorf("got data: %v, want data: %v\n", analysis.Data, []Region{{0x604, 0x605}})
+	}
+}
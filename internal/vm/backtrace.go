@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+	"miya/internal/memory"
+	"miya/internal/screen"
+	"strings"
+)
+
+// Call is one line of a backtrace: the address running in a subroutine and the frame that entered it.
+// The outermost line is the main program and has no frame
+type Call struct {
+	PC    uint16
+	Frame *memory.Frame
+}
+
+// NOTE: Innermost call first, the PC of every outer call is the CALL into the one before it
+func (vm *VirtualMachine) Backtrace() []Call {
+	frames := vm.stack.Frames()
+	calls := make([]Call, 0, len(frames)+1)
+
+	pc := vm.registers.PC
+	for i := len(frames) - 1; i >= 0; i-- {
+		calls = append(calls, Call{pc, &frames[i]})
+		pc = frames[i].Caller
+	}
+
+	return append(calls, Call{pc, nil})
+}
+
+func (call Call) String() string {
+	if call.Frame == nil {
+		return fmt.Sprintf("0x%03x in main", call.PC)
+	}
+
+	return fmt.Sprintf("0x%03x in sub_0x%03x, called from 0x%03x at cycle %d", call.PC, call.Frame.Callee, call.Frame.Caller, call.Frame.Cycle)
+}
+
+func formatBacktrace(calls []Call, registers bool) string {
+	var b strings.Builder
+
+	for i, call := range calls {
+		fmt.Fprintf(&b, "#%d %s\n", i, call)
+
+		if registers && call.Frame != nil {
+			fmt.Fprintf(&b, "   entered with V: %v, I: 0x%03x\n", call.Frame.V, call.Frame.I)
+		}
+	}
+
+	return b.String()
+}
+
+// NOTE: A fault is reported once until the next reset, a rom stuck in a recursion would flood the log otherwise.
+// The machine pauses on the faulting instruction, so the backtrace shows the state on screen
+func (vm *VirtualMachine) fault(err error) {
+	if vm.faulted {
+		return
+	}
+
+	vm.faulted = true
+	vm.paused = true
+	screen.SendStatus(fmt.Sprintf("Paused | %v", err), 0)
+
+	log.Printf("%v, PC: 0x%03x, cycle %d\nV: %v, I: 0x%03x\n%s", err, vm.registers.PC, vm.executed, vm.registers.V, vm.registers.I, formatBacktrace(vm.Backtrace(), true))
+}
+
+func (vm *VirtualMachine) newFrame(callee uint16) memory.Frame {
+	frame := memory.Frame{
+		Caller: vm.registers.PC,
+		Callee: callee,
+		Cycle:  vm.executed,
+		I:      vm.registers.I,
+	}
+
+	copy(frame.V[:], vm.registers.V)
+
+	return frame
+}
@@ -0,0 +1,63 @@
+package vm
+
+import (
+	"miya/internal/memory"
+	"miya/internal/screen"
+	"testing"
+)
+
+// NOTE: A loop over the common instructions: arithmetic, skips, BCD to memory, a call and a sprite
+var benchROM = []byte{
+	0x60, 0x00, // 0x200: LD V0, 0x00
+	0x70, 0x01, // 0x202: ADD V0, 0x01
+	0x81, 0x04, // 0x204: ADD V1, V0
+	0x82, 0x13, // 0x206: XOR V2, V1
+	0x30, 0x80, // 0x208: SE V0, 0x80
+	0x63, 0x05, // 0x20A: LD V3, 0x05
+	0xA3, 0x00, // 0x20C: LD I, 0x300
+	0xF0, 0x33, // 0x20E: LD B, V0
+	0x22, 0x18, // 0x210: CALL 0x218
+	0xD3, 0x35, // 0x212: DRW V3, V3, 5
+	0x12, 0x02, // 0x214: JP 0x202
+	0x00, 0x00,
+	0xF0, 0x1E, // 0x218: ADD I, V0
+	0x00, 0xEE, // 0x21A: RET
+}
+
+func newBenchMachine(b *testing.B) *VirtualMachine {
+	machine := NewVirtualMachine(memory.NewMemory(memory.CHIP8_MEMORY_SIZE), memory.NewStack(memory.CHIP8_STACK_SIZE), &screen.MockWindow{}, 1000, false)
+
+	if err := machine.LoadROM(benchROM); err != nil {
+		b.Fatalf("machine.LoadROM(): %v\n", err)
+	}
+
+	return machine
+}
+
+func BenchmarkStep(b *testing.B) {
+	machine := newBenchMachine(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		machine.Step()
+	}
+}
+
+func BenchmarkRunFrame(b *testing.B) {
+	machine := newBenchMachine(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		machine.RunFrame()
+	}
+}
+
+func BenchmarkRunFrame_jit(b *testing.B) {
+	machine := newBenchMachine(b)
+	machine.SetJIT(true)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		machine.RunFrame()
+	}
+}
@@ -0,0 +1,173 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"miya/internal/profile"
+	"strings"
+)
+
+type Instruction struct {
+	Address uint16 `json:"address"`
+	Opcode  uint16 `json:"opcode"`
+}
+
+type Block struct {
+	Start        uint16        `json:"start"`
+	End          uint16        `json:"end"`
+	Instructions []Instruction `json:"instructions"`
+	Count        uint64        `json:"count"`
+}
+
+type CFG struct {
+	Blocks []Block `json:"blocks"`
+	Edges  []Edge  `json:"edges"`
+}
+
+// NOTE: Blocks start at the load address, at every target of a jump, call or skip and after every instruction that branches
+func BuildCFG(program *Program) *CFG {
+	var cfg CFG
+
+	leaders := map[uint16]bool{program.load: true}
+	for _, address := range program.Addresses() {
+		edges := program.Edges(address)
+		if len(edges) == 1 && edges[0].Kind == EDGE_NEXT {
+			continue
+		}
+
+		for _, edge := range edges {
+			leaders[edge.To] = true
+		}
+
+		leaders[address+program.size(address)] = true
+	}
+
+	var block *Block
+	for _, address := range program.Addresses() {
+		if block == nil || leaders[address] || block.End+program.size(block.End) != address {
+			cfg.Blocks = append(cfg.Blocks, Block{Start: address})
+			block = &cfg.Blocks[len(cfg.Blocks)-1]
+		}
+
+		block.End = address
+		block.Instructions = append(block.Instructions, Instruction{address, program.instructions[address].value()})
+	}
+
+	for _, block := range cfg.Blocks {
+		for _, edge := range program.Edges(block.End) {
+			cfg.Edges = append(cfg.Edges, Edge{block.Start, edge.To, edge.Kind})
+		}
+	}
+
+	return &cfg
+}
+
+func (cfg *CFG) find(address uint16) (*Block, int) {
+	for i := range cfg.Blocks {
+		for k, instruction := range cfg.Blocks[i].Instructions {
+			if instruction.Address == address {
+				return &cfg.Blocks[i], k
+			}
+		}
+	}
+
+	return nil, 0
+}
+
+// NOTE: Only the instruction right before in the same block counts, a jump into the block could skip it
+func (cfg *CFG) copied(address uint16, op opcode) bool {
+	block, i := cfg.find(address)
+	if block == nil || i == 0 {
+		return false
+	}
+
+	return block.Instructions[i-1].Opcode == VX_VY|uint16(op.x())<<8|uint16(op.y())<<4
+}
+
+// NOTE: Follows every path after address until an instruction uses the value or overwrites it, BNNN and RET end a path
+func (cfg *CFG) reaches(address uint16, uses, overwrites func(opcode) bool) bool {
+	block, i := cfg.find(address)
+	if block == nil {
+		return false
+	}
+
+	return cfg.walk(block, i+1, uses, overwrites, make(map[uint16]bool))
+}
+
+func (cfg *CFG) walk(block *Block, start int, uses, overwrites func(opcode) bool, visited map[uint16]bool) bool {
+	for _, instruction := range block.Instructions[start:] {
+		op := newOpcode(instruction.Opcode)
+
+		if uses(op) {
+			return true
+		}
+
+		if overwrites(op) {
+			return false
+		}
+	}
+
+	for _, edge := range cfg.Edges {
+		if edge.From != block.Start || edge.Kind == EDGE_COMPUTED || visited[edge.To] {
+			continue
+		}
+
+		visited[edge.To] = true
+
+		if next, i := cfg.find(edge.To); next != nil && i == 0 && cfg.walk(next, 0, uses, overwrites, visited) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NOTE: Every instruction of a block runs as often as the first one, so the count of the block is the count of its start
+func (cfg *CFG) MergeProfile(profile *profile.Profile) {
+	for i := range cfg.Blocks {
+		cfg.Blocks[i].Count = profile.Counts[cfg.Blocks[i].Start]
+	}
+}
+
+func (cfg *CFG) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(cfg)
+}
+
+// NOTE: BNNN edges point at NNN, the real target is NNN + V0, so they are dashed and the target may have no block
+func (cfg *CFG) WriteDOT(w io.Writer, counts bool) error {
+	var b strings.Builder
+
+	b.WriteString("digraph cfg {\n")
+	b.WriteString("\tnode [shape=box fontname=\"monospace\"];\n")
+
+	for _, block := range cfg.Blocks {
+		var label strings.Builder
+		for _, instruction := range block.Instructions {
+			fmt.Fprintf(&label, "0x%03x: %04X\\l", instruction.Address, instruction.Opcode)
+		}
+
+		if counts {
+			fmt.Fprintf(&label, "count: %d\\l", block.Count)
+		}
+
+		fmt.Fprintf(&b, "\t\"0x%03x\" [label=\"%s\"];\n", block.Start, label.String())
+	}
+
+	for _, edge := range cfg.Edges {
+		style := ""
+		if edge.Kind == EDGE_COMPUTED {
+			style = " style=dashed"
+		}
+
+		fmt.Fprintf(&b, "\t\"0x%03x\" -> \"0x%03x\" [label=\"%s\"%s];\n", edge.From, edge.To, edge.Kind, style)
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
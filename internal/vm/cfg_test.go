@@ -0,0 +1,75 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"miya/internal/profile"
+	"strings"
+	"testing"
+)
+
+func TestBuildCFG(t *testing.T) {
+	rom := []byte{
+		0x60, 0x00, // 0x200 LD V0, 0x00
+		0x30, 0x01, // 0x202 SE V0, 0x01
+		0x22, 0x0A, // 0x204 CALL 0x20A
+		0x70, 0x01, // 0x206 ADD V0, 0x01
+		0x12, 0x02, // 0x208 JP 0x202
+		0xB2, 0x10, // 0x20A JP V0, 0x210
+	}
+
+	cfg := BuildCFG(Disassemble(rom, ROM_ADDRESS))
+
+	var starts []uint16
+	for _, block := range cfg.Blocks {
+		starts = append(starts, block.Start)
+	}
+
+	if want := []uint16{0x200, 0x202, 0x204, 0x206, 0x20A}; fmt.Sprint(starts) != fmt.Sprint(want) {
+		t.Errorf("got blocks: %x, want blocks: %x\n", starts, want)
+	}
+
+	edges := make(map[Edge]bool)
+	for _, edge := range cfg.Edges {
+		edges[edge] = true
+	}
+
+	for _, edge := range []Edge{
+		{0x200, 0x202, EDGE_NEXT},
+		{0x202, 0x204, EDGE_NEXT},
+		{0x202, 0x206, EDGE_SKIP},
+		{0x204, 0x20A, EDGE_CALL},
+		{0x204, 0x206, EDGE_NEXT},
+		{0x206, 0x202, EDGE_JUMP},
+		{0x20A, 0x210, EDGE_COMPUTED},
+	} {
+		if !edges[edge] {
+			t.Errorf("got edges: %v, want edge: %v\n", cfg.Edges, edge)
+		}
+	}
+
+	if len(cfg.Edges) != 7 {
+		t.Errorf("got edges: %d, want edges: %d\n", len(cfg.Edges), 7)
+	}
+}
+
+func TestCFG_WriteDOT(t *testing.T) {
+	var buffer bytes.Buffer
+
+	p := profile.New()
+	p.Add(0x200)
+	p.Add(0x200)
+
+	cfg := BuildCFG(Disassemble([]byte{0x12, 0x00}, ROM_ADDRESS))
+	cfg.MergeProfile(p)
+
+	if err := cfg.WriteDOT(&buffer, true); err != nil {
+		t.Fatalf("cfg.WriteDOT(): %v\n", err)
+	}
+
+	for _, want := range []string{`"0x200" [label="0x200: 1200\lcount: 2\l"];`, `"0x200" -> "0x200" [label="jump"];`} {
+		if !strings.Contains(buffer.String(), want) {
+			t.Errorf("got dot: %s, want line: %s\n", buffer.String(), want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package vm
+
+import (
+	"miya/internal/coverage"
+)
+
+func (vm *VirtualMachine) SetCoverage(coverage *coverage.Coverage) {
+	vm.coverage = coverage
+}
+
+func (vm *VirtualMachine) cover(pc uint16, op opcode) {
+	vm.coverage.Add(pc)
+
+	if skips(op) {
+		vm.coverage.AddBranch(pc, vm.registers.PC != pc+2)
+	}
+}
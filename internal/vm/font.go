@@ -0,0 +1,195 @@
+package vm
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+const FONT_CHIP8 = "chip-8"
+const FONT_VIP = "vip"
+const FONT_DREAM6800 = "dream6800"
+const FONT_ETI660 = "eti-660"
+const FONT_FISHNCHIPS = "fishnchips"
+const FONT_SCHIP = "schip"
+const FONT_XOCHIP = "xo-chip"
+
+const SMALL_GLYPH_SIZE = 5
+const LARGE_GLYPH_SIZE = 10
+const FONT_GLYPHS = 0x10
+
+// NOTE: The large font goes right after the small one, below every load address
+const LARGE_FONT_ADDRESS = 0x050
+
+// NOTE: Small fonts are 4x5 glyphs for 0 to F, FX29 points I at them
+var smallFonts = map[string][]byte{
+	FONT_CHIP8: {
+		0xF0, 0x90, 0x90, 0x90, 0xF0,
+		0x20, 0x60, 0x20, 0x20, 0x70,
+		0xF0, 0x10, 0xF0, 0x80, 0xF0,
+		0xF0, 0x10, 0xF0, 0x10, 0xF0,
+		0x90, 0x90, 0xF0, 0x10, 0x10,
+		0xF0, 0x80, 0xF0, 0x10, 0xF0,
+		0xF0, 0x80, 0xF0, 0x90, 0xF0,
+		0xF0, 0x10, 0x20, 0x40, 0x40,
+		0xF0, 0x90, 0xF0, 0x90, 0xF0,
+		0xF0, 0x90, 0xF0, 0x10, 0xF0,
+		0xF0, 0x90, 0xF0, 0x90, 0x90,
+		0xE0, 0x90, 0xE0, 0x90, 0xE0,
+		0xF0, 0x80, 0x80, 0x80, 0xF0,
+		0xE0, 0x90, 0x90, 0x90, 0xE0,
+		0xF0, 0x80, 0xF0, 0x80, 0xF0,
+		0xF0, 0x80, 0xF0, 0x80, 0x80,
+	},
+	FONT_VIP: {
+		0xF0, 0x90, 0x90, 0x90, 0xF0,
+		0x60, 0x20, 0x20, 0x20, 0x70,
+		0xF0, 0x10, 0xF0, 0x80, 0xF0,
+		0xF0, 0x10, 0xF0, 0x10, 0xF0,
+		0xA0, 0xA0, 0xF0, 0x20, 0x20,
+		0xF0, 0x80, 0xF0, 0x10, 0xF0,
+		0xF0, 0x80, 0xF0, 0x90, 0xF0,
+		0xF0, 0x10, 0x10, 0x10, 0x10,
+		0xF0, 0x90, 0xF0, 0x90, 0xF0,
+		0xF0, 0x90, 0xF0, 0x10, 0xF0,
+		0xF0, 0x90, 0xF0, 0x90, 0x90,
+		0xF0, 0x50, 0x70, 0x50, 0xF0,
+		0xF0, 0x80, 0x80, 0x80, 0xF0,
+		0xF0, 0x50, 0x50, 0x50, 0xF0,
+		0xF0, 0x80, 0xF0, 0x80, 0xF0,
+		0xF0, 0x80, 0xF0, 0x80, 0x80,
+	},
+	FONT_DREAM6800: {
+		0xE0, 0xA0, 0xA0, 0xA0, 0xE0,
+		0x40, 0x40, 0x40, 0x40, 0x40,
+		0xE0, 0x20, 0xE0, 0x80, 0xE0,
+		0xE0, 0x20, 0xE0, 0x20, 0xE0,
+		0x80, 0xA0, 0xA0, 0xE0, 0x20,
+		0xE0, 0x80, 0xE0, 0x20, 0xE0,
+		0xE0, 0x80, 0xE0, 0xA0, 0xE0,
+		0xE0, 0x20, 0x20, 0x20, 0x20,
+		0xE0, 0xA0, 0xE0, 0xA0, 0xE0,
+		0xE0, 0xA0, 0xE0, 0x20, 0xE0,
+		0xE0, 0xA0, 0xE0, 0xA0, 0xA0,
+		0xC0, 0xA0, 0xE0, 0xA0, 0xC0,
+		0xE0, 0x80, 0x80, 0x80, 0xE0,
+		0xC0, 0xA0, 0xA0, 0xA0, 0xC0,
+		0xE0, 0x80, 0xE0, 0x80, 0xE0,
+		0xE0, 0x80, 0xC0, 0x80, 0x80,
+	},
+	FONT_ETI660: {
+		0xE0, 0xA0, 0xA0, 0xA0, 0xE0,
+		0x20, 0x20, 0x20, 0x20, 0x20,
+		0xE0, 0x20, 0xE0, 0x80, 0xE0,
+		0xE0, 0x20, 0xE0, 0x20, 0xE0,
+		0xA0, 0xA0, 0xE0, 0x20, 0x20,
+		0xE0, 0x80, 0xE0, 0x20, 0xE0,
+		0xE0, 0x80, 0xE0, 0xA0, 0xE0,
+		0xE0, 0x20, 0x20, 0x20, 0x20,
+		0xE0, 0xA0, 0xE0, 0xA0, 0xE0,
+		0xE0, 0xA0, 0xE0, 0x20, 0xE0,
+		0xE0, 0xA0, 0xE0, 0xA0, 0xA0,
+		0x80, 0x80, 0xE0, 0xA0, 0xE0,
+		0xE0, 0x80, 0x80, 0x80, 0xE0,
+		0x20, 0x20, 0xE0, 0xA0, 0xE0,
+		0xE0, 0x80, 0xE0, 0x80, 0xE0,
+		0xE0, 0x80, 0xC0, 0x80, 0x80,
+	},
+	FONT_FISHNCHIPS: {
+		0x60, 0xA0, 0xA0, 0xA0, 0xC0,
+		0x40, 0xC0, 0x40, 0x40, 0xE0,
+		0xC0, 0x20, 0x40, 0x80, 0xE0,
+		0xC0, 0x20, 0x40, 0x20, 0xC0,
+		0x20, 0xA0, 0xE0, 0x20, 0x20,
+		0xE0, 0x80, 0xC0, 0x20, 0xC0,
+		0x40, 0x80, 0xC0, 0xA0, 0x40,
+		0xE0, 0x20, 0x60, 0x40, 0x40,
+		0x40, 0xA0, 0x40, 0xA0, 0x40,
+		0x40, 0xA0, 0x60, 0x20, 0x40,
+		0x40, 0xA0, 0xE0, 0xA0, 0xA0,
+		0xC0, 0xA0, 0xC0, 0xA0, 0xC0,
+		0x60, 0x80, 0x80, 0x80, 0x60,
+		0xC0, 0xA0, 0xA0, 0xA0, 0xC0,
+		0xE0, 0x80, 0xC0, 0x80, 0xE0,
+		0xE0, 0x80, 0xC0, 0x80, 0x80,
+	},
+}
+
+// NOTE: Large fonts are 8x10 glyphs, FX30 points I at them. SCHIP only has the digits, XO-CHIP all of 0 to F
+var largeFonts = map[string][]byte{
+	FONT_SCHIP: {
+		0x3C, 0x7E, 0xE7, 0xC3, 0xC3, 0xC3, 0xC3, 0xE7, 0x7E, 0x3C,
+		0x18, 0x38, 0x58, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x3C,
+		0x3E, 0x7F, 0xC3, 0x06, 0x0C, 0x18, 0x30, 0x60, 0xFF, 0xFF,
+		0x3C, 0x7E, 0xC3, 0x03, 0x0E, 0x0E, 0x03, 0xC3, 0x7E, 0x3C,
+		0x06, 0x0E, 0x1E, 0x36, 0x66, 0xC6, 0xFF, 0xFF, 0x06, 0x06,
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFE, 0x03, 0xC3, 0x7E, 0x3C,
+		0x3E, 0x7C, 0xC0, 0xC0, 0xFC, 0xFE, 0xC3, 0xC3, 0x7E, 0x3C,
+		0xFF, 0xFF, 0x03, 0x06, 0x0C, 0x18, 0x30, 0x60, 0x60, 0x60,
+		0x3C, 0x7E, 0xC3, 0xC3, 0x7E, 0x7E, 0xC3, 0xC3, 0x7E, 0x3C,
+		0x3C, 0x7E, 0xC3, 0xC3, 0x7F, 0x3F, 0x03, 0x03, 0x3E, 0x7C,
+	},
+	FONT_XOCHIP: {
+		0xFF, 0xFF, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF,
+		0x18, 0x78, 0x78, 0x18, 0x18, 0x18, 0x18, 0x18, 0xFF, 0xFF,
+		0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF,
+		0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF,
+		0xC3, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0x03, 0x03, 0x03, 0x03,
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF,
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF,
+		0xFF, 0xFF, 0x03, 0x03, 0x06, 0x0C, 0x18, 0x18, 0x18, 0x18,
+		0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF,
+		0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF,
+		0x7E, 0xFF, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xC3,
+		0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC,
+		0x3C, 0xFF, 0xC3, 0xC0, 0xC0, 0xC0, 0xC0, 0xC3, 0xFF, 0x3C,
+		0xFC, 0xFE, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFE, 0xFC,
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF,
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xC0, 0xC0,
+	},
+}
+
+func SmallFontNames() []string {
+	return fontNames(smallFonts)
+}
+
+func LargeFontNames() []string {
+	return fontNames(largeFonts)
+}
+
+// NOTE: A font is either a built in name or a binary file with up to 16 glyphs
+func LoadSmallFont(name string) ([]byte, error) {
+	return loadFont(name, smallFonts, SMALL_GLYPH_SIZE)
+}
+
+func LoadLargeFont(name string) ([]byte, error) {
+	return loadFont(name, largeFonts, LARGE_GLYPH_SIZE)
+}
+
+func loadFont(name string, fonts map[string][]byte, glyph int) ([]byte, error) {
+	if font, ok := fonts[name]; ok {
+		return font, nil
+	}
+
+	font, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown font: %q, want one of %v or a file: %v", name, fontNames(fonts), err)
+	}
+
+	if len(font) == 0 || len(font)%glyph != 0 || len(font) > FONT_GLYPHS*glyph {
+		return nil, fmt.Errorf("invalid font %s: %d bytes, want up to %d glyphs of %d bytes", name, len(font), FONT_GLYPHS, glyph)
+	}
+
+	return font, nil
+}
+
+func fontNames(fonts map[string][]byte) []string {
+	names := make([]string, 0, len(fonts))
+	for name := range fonts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,40 @@
+package vm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFonts(t *testing.T) {
+	for _, name := range SmallFontNames() {
+		if len(smallFonts[name]) != FONT_GLYPHS*SMALL_GLYPH_SIZE {
+			t.Errorf("got %d bytes, want %d bytes for %s\n", len(smallFonts[name]), FONT_GLYPHS*SMALL_GLYPH_SIZE, name)
+		}
+	}
+
+	if len(largeFonts[FONT_SCHIP]) != 10*LARGE_GLYPH_SIZE || len(largeFonts[FONT_XOCHIP]) != FONT_GLYPHS*LARGE_GLYPH_SIZE {
+		t.Errorf("got %d and %d bytes, want %d and %d bytes for the large fonts\n", len(largeFonts[FONT_SCHIP]), len(largeFonts[FONT_XOCHIP]), 10*LARGE_GLYPH_SIZE, FONT_GLYPHS*LARGE_GLYPH_SIZE)
+	}
+}
+
+func TestLoadFont_file(t *testing.T) {
+	dir := t.TempDir()
+
+	fname := filepath.Join(dir, "font.bin")
+	if err := os.WriteFile(fname, make([]byte, 3*SMALL_GLYPH_SIZE), 0644); err != nil {
+		t.Fatalf("os.WriteFile(): %v\n", err)
+	}
+
+	if font, err := LoadSmallFont(fname); err != nil || len(font) != 3*SMALL_GLYPH_SIZE {
+		t.Errorf("got %d bytes, err: %v, want %d bytes\n", len(font), err, 3*SMALL_GLYPH_SIZE)
+	}
+
+	if _, err := LoadLargeFont(fname); err == nil {
+		t.Errorf("got err: nil, want err for 15 bytes of large glyphs\n")
+	}
+
+	if _, err := LoadSmallFont(filepath.Join(dir, "missing.bin")); err == nil {
+		t.Errorf("got err: nil, want err for a missing file\n")
+	}
+}
@@ -0,0 +1,240 @@
+package vm
+
+import (
+	"miya/internal/memory"
+)
+
+// NOTE: A block ends at the first jump, call, return, skip or key wait, or after this many instructions
+const JIT_BLOCK_SIZE = 0x20
+
+// JIT runs basic blocks as chains of closures built once per block and cached by address, so the
+// instructions are fetched and decoded once instead of on every cycle. A write into a block throws it
+// away, and the bytes written are run by the interpreter from then on, so self-modifying code keeps working
+type JIT struct {
+	vm     *VirtualMachine
+	blocks []*block
+	code   []bool
+	smc    []bool
+}
+
+type block struct {
+	start int
+	end   int
+	code  []compiled
+	valid bool
+}
+
+type compiled struct {
+	op  opcode
+	run func()
+}
+
+// jitBus tells the JIT about every write, whoever makes it
+type jitBus struct {
+	memory.Bus
+	jit *JIT
+}
+
+func newJIT(vm *VirtualMachine, size int) *JIT {
+	return &JIT{
+		vm:     vm,
+		blocks: make([]*block, size),
+		code:   make([]bool, size),
+		smc:    make([]bool, size),
+	}
+}
+
+// NOTE: The JIT wraps the memory of the machine, the debugger, the profiler, coverage and the access map
+// need every instruction and turn it off while they are set
+func (vm *VirtualMachine) SetJIT(enabled bool) {
+	if enabled && vm.jit == nil {
+		vm.jit = newJIT(vm, vm.memory.Size())
+		vm.memory = &jitBus{vm.memory, vm.jit}
+	}
+
+	if !enabled && vm.jit != nil {
+		vm.memory = vm.memory.(*jitBus).Bus
+		vm.jit = nil
+	}
+}
+
+func (vm *VirtualMachine) jitEnabled() bool {
+	return vm.jit != nil && !vm.debugMode && vm.profiler == nil && vm.coverage == nil && vm.access == nil
+}
+
+func (bus *jitBus) Write(addr uint16, data byte) {
+	bus.Bus.Write(addr, data)
+	bus.jit.write(addr)
+}
+
+func (bus *jitBus) WriteArray(addr uint16, data []byte) error {
+	err := bus.Bus.WriteArray(addr, data)
+	bus.jit.invalidate(int(addr), len(data))
+
+	return err
+}
+
+func (bus *jitBus) Reset() {
+	bus.Bus.Reset()
+	bus.jit.flush()
+}
+
+// NOTE: Runs like the interpreter loop of RunFrame, the cost of every instruction is charged one at a time
+func (jit *JIT) run(cycles, budget uint64) uint64 {
+	vm := jit.vm
+
+	for cycles < budget {
+		pc := vm.registers.PC
+
+		b := jit.lookup(pc)
+		if b == nil {
+			op := newOpcode(vm.memory.ReadOpcode(pc))
+			vx := vm.registers.V[op.x()]
+
+			vm.exec(op)
+			cycles = vm.charge(cycles, budget, op, vx, pc)
+
+			continue
+		}
+
+		for _, c := range b.code {
+			vx := vm.registers.V[c.op.x()]
+
+			c.run()
+			vm.executed++
+			cycles = vm.charge(cycles, budget, c.op, vx, pc)
+
+			if cycles >= budget || !b.valid || vm.registers.PC != pc+2 {
+				break
+			}
+
+			pc += 2
+		}
+	}
+
+	return cycles
+}
+
+func (jit *JIT) lookup(pc uint16) *block {
+	addr := int(pc)
+	if addr+2 > len(jit.blocks) || jit.smc[addr] || jit.smc[addr+1] {
+		return nil
+	}
+
+	if jit.blocks[addr] == nil {
+		jit.blocks[addr] = jit.compile(addr)
+	}
+
+	return jit.blocks[addr]
+}
+
+func (jit *JIT) compile(start int) *block {
+	b := block{start: start, valid: true}
+
+	for addr := start; len(b.code) < JIT_BLOCK_SIZE && addr+2 <= len(jit.blocks) && !jit.smc[addr] && !jit.smc[addr+1]; addr += 2 {
+		op := newOpcode(jit.vm.memory.ReadOpcode(uint16(addr)))
+		b.code = append(b.code, compiled{op, jit.vm.closure(op)})
+		b.end = addr + 2
+
+		jit.code[addr] = true
+		jit.code[addr+1] = true
+
+		if endsBlock(op) {
+			break
+		}
+	}
+
+	return &b
+}
+
+func endsBlock(op opcode) bool {
+	switch op.t() {
+	case CLC:
+		return op.nnn() != 0x0E0
+	case JP, CALL, JP_V0:
+		return true
+	case LDF:
+		return op.nn() == 0x0A
+	}
+
+	return skips(op)
+}
+
+// NOTE: Bytes that were compiled and are written by the program are self-modifying code, the interpreter runs them
+func (jit *JIT) write(addr uint16) {
+	if int(addr) >= len(jit.code) || !jit.code[addr] {
+		return
+	}
+
+	jit.smc[addr] = true
+	jit.invalidate(int(addr), 1)
+}
+
+func (jit *JIT) invalidate(addr, length int) {
+	start := addr - 2*JIT_BLOCK_SIZE + 1
+	if start < 0 {
+		start = 0
+	}
+
+	for i := start; i < addr+length && i < len(jit.blocks); i++ {
+		if b := jit.blocks[i]; b != nil && b.end > addr {
+			b.valid = false
+			jit.blocks[i] = nil
+		}
+	}
+}
+
+func (jit *JIT) flush() {
+	for i := range jit.blocks {
+		if jit.blocks[i] != nil {
+			jit.blocks[i].valid = false
+			jit.blocks[i] = nil
+		}
+
+		jit.code[i] = false
+		jit.smc[i] = false
+	}
+}
+
+// NOTE: The common register instructions get their own closures, everything else calls the interpreter with the decoded opcode
+func (vm *VirtualMachine) closure(op opcode) func() {
+	x, y, nn, nnn := op.x(), op.y(), op.nn(), op.nnn()
+
+	switch {
+	case op.t() == LD_VX:
+		return func() {
+			vm.registers.V[x] = nn
+			vm.registers.PC += 2
+		}
+	case op.t() == ADD:
+		return func() {
+			vm.registers.V[x] += nn
+			vm.registers.PC += 2
+		}
+	case op.t() == LD_I:
+		return func() {
+			vm.registers.I = nnn
+			vm.registers.PC += 2
+		}
+	case op.t() == VX_VY && op.n() == 0x0:
+		return func() {
+			vm.registers.V[x] = vm.registers.V[y]
+			vm.registers.PC += 2
+		}
+	case op.t() == JP:
+		return func() {
+			vm.registers.PC = nnn
+		}
+	case op.t() == LDF && op.nn() == 0x1E:
+		return func() {
+			vm.registers.I += uint16(vm.registers.V[x])
+			vm.registers.PC += 2
+		}
+	}
+
+	run := vm.instructions[op.value()>>12]
+
+	return func() {
+		run(op)
+	}
+}
@@ -0,0 +1,108 @@
+package vm
+
+import (
+	"io"
+	"log"
+	"math/rand"
+	"miya/internal/memory"
+	"miya/internal/screen"
+	"reflect"
+	"testing"
+)
+
+// NOTE: Stores V0 and V1 over the ADD at 0x20A, so V2 is added a bigger step every time around the loop
+var smcROM = []byte{
+	0x60, 0x72, // 0x200: LD V0, 0x72
+	0x61, 0x05, // 0x202: LD V1, 0x05
+	0xA2, 0x0A, // 0x204: LD I, 0x20A
+	0xF1, 0x55, // 0x206: LD [I], V1
+	0x71, 0x01, // 0x208: ADD V1, 0x01
+	0x72, 0x00, // 0x20A: ADD V2, 0x00
+	0x12, 0x04, // 0x20C: JP 0x204
+}
+
+func newDiffMachine(t *testing.T, rom []byte, jit bool) *VirtualMachine {
+	machine := NewVirtualMachine(memory.NewMemory(memory.CHIP8_MEMORY_SIZE), memory.NewStack(memory.CHIP8_STACK_SIZE), &screen.MockWindow{}, 100, false)
+	machine.SetSeed(1)
+	machine.SetJIT(jit)
+
+	if err := machine.LoadROM(rom); err != nil {
+		t.Fatalf("machine.LoadROM(): %v\n", err)
+	}
+
+	return machine
+}
+
+// NOTE: Runs the rom on the interpreter and on the JIT and compares the whole state after every frame
+func assertSameAsInterpreter(t *testing.T, name string, rom []byte, frames int) *VirtualMachine {
+	interpreter := newDiffMachine(t, rom, false)
+	jit := newDiffMachine(t, rom, true)
+
+	for frame := 0; frame < frames; frame++ {
+		interpreter.RunFrame()
+		jit.RunFrame()
+
+		if want, got := interpreter.SaveState(), jit.SaveState(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("[%s] got state after frame %d: PC 0x%03x, V %v, I 0x%03x, want state: PC 0x%03x, V %v, I 0x%03x\n", name, frame, got.registers.PC, got.registers.V, got.registers.I, want.registers.PC, want.registers.V, want.registers.I)
+		}
+	}
+
+	return jit
+}
+
+func TestJIT(t *testing.T) {
+	jit := assertSameAsInterpreter(t, "bench rom", benchROM, 60)
+
+	if jit.jit.blocks[0x202] == nil {
+		t.Errorf("got no block at 0x202, want the loop compiled\n")
+	}
+}
+
+func TestJIT_smc(t *testing.T) {
+	jit := assertSameAsInterpreter(t, "smc rom", smcROM, 60)
+
+	if !jit.jit.smc[0x20A] || !jit.jit.smc[0x20B] {
+		t.Errorf("got the store into 0x20A not marked as self-modifying code\n")
+	}
+
+	if b := jit.jit.blocks[0x204]; b == nil || !b.valid || b.end != 0x20A {
+		t.Errorf("got block at 0x204: %v, want the loop compiled up to the modified ADD\n", b)
+	}
+}
+
+func TestJIT_reset(t *testing.T) {
+	machine := newDiffMachine(t, smcROM, true)
+	machine.RunFrame()
+	machine.HardReset()
+
+	if machine.jit.smc[0x20A] || machine.jit.blocks[0x204] != nil {
+		t.Errorf("got blocks or self-modifying code left after a reset, want a flushed JIT\n")
+	}
+
+	machine.SetJIT(false)
+	if _, ok := machine.memory.(*jitBus); ok || machine.jit != nil {
+		t.Errorf("got the JIT still set, want the plain memory back\n")
+	}
+}
+
+// NOTE: Random roms hit every instruction, data as code, stack faults and stores into the code itself
+func TestJIT_random(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200; i++ {
+		rom := make([]byte, 0x100)
+		rng.Read(rom)
+
+		// NOTE: Keep most jumps inside the rom, so the programs run a while before they fall off into zeros
+		for k := 0; k < len(rom); k += 2 {
+			if rom[k]>>4 == 0x1 || rom[k]>>4 == 0x2 || rom[k]>>4 == 0xA {
+				rom[k] = rom[k]&0xF0 | 0x02
+			}
+		}
+
+		assertSameAsInterpreter(t, "random rom", rom, 20)
+	}
+}
@@ -0,0 +1,128 @@
+package vm
+
+import (
+	"fmt"
+)
+
+var arithmetic = map[byte]string{
+	0x0: "LD",
+	0x1: "OR",
+	0x2: "AND",
+	0x3: "XOR",
+	0x4: "ADD",
+	0x5: "SUB",
+	0x6: "SHR",
+	0x7: "SUBN",
+	0xE: "SHL",
+}
+
+var loads = map[byte]string{
+	0x07: "LD V%X, DT",
+	0x0A: "LD V%X, K",
+	0x15: "LD DT, V%X",
+	0x18: "LD ST, V%X",
+	0x1E: "ADD I, V%X",
+	0x29: "LD F, V%X",
+	0x30: "LD HF, V%X",
+	0x33: "LD B, V%X",
+	0x3A: "PITCH V%X",
+	0x55: "LD [I], V%X",
+	0x65: "LD V%X, [I]",
+	0x75: "LD R, V%X",
+	0x85: "LD V%X, R",
+}
+
+// Mnemonic is the assembly of an opcode in the syntax of Cowgod's reference with the SCHIP and
+// XO-CHIP extensions, opcodes that are not instructions are shown as data
+func Mnemonic(value uint16) string {
+	op := newOpcode(value)
+
+	switch op.t() {
+	case CLC:
+		return clcMnemonic(op)
+	case JP:
+		return fmt.Sprintf("JP 0x%03X", op.nnn())
+	case CALL:
+		return fmt.Sprintf("CALL 0x%03X", op.nnn())
+	case SE_VX:
+		return fmt.Sprintf("SE V%X, 0x%02X", op.x(), op.nn())
+	case SNE:
+		return fmt.Sprintf("SNE V%X, 0x%02X", op.x(), op.nn())
+	case SE_VX_VY:
+		switch op.n() {
+		case 0x0:
+			return fmt.Sprintf("SE V%X, V%X", op.x(), op.y())
+		case 0x2:
+			return fmt.Sprintf("SAVE V%X - V%X", op.x(), op.y())
+		case 0x3:
+			return fmt.Sprintf("LOAD V%X - V%X", op.x(), op.y())
+		}
+	case LD_VX:
+		return fmt.Sprintf("LD V%X, 0x%02X", op.x(), op.nn())
+	case ADD:
+		return fmt.Sprintf("ADD V%X, 0x%02X", op.x(), op.nn())
+	case VX_VY:
+		if name, ok := arithmetic[op.n()]; ok {
+			return fmt.Sprintf("%s V%X, V%X", name, op.x(), op.y())
+		}
+	case SNE_VX_VY:
+		if op.n() == 0 {
+			return fmt.Sprintf("SNE V%X, V%X", op.x(), op.y())
+		}
+	case LD_I:
+		return fmt.Sprintf("LD I, 0x%03X", op.nnn())
+	case JP_V0:
+		return fmt.Sprintf("JP V0, 0x%03X", op.nnn())
+	case RND:
+		return fmt.Sprintf("RND V%X, 0x%02X", op.x(), op.nn())
+	case DRW:
+		return fmt.Sprintf("DRW V%X, V%X, %d", op.x(), op.y(), op.n())
+	case SKP:
+		switch op.nn() {
+		case 0x9E:
+			return fmt.Sprintf("SKP V%X", op.x())
+		case 0xA1:
+			return fmt.Sprintf("SKNP V%X", op.x())
+		}
+	case LDF:
+		switch {
+		case op.value() == 0xF000:
+			return "LD I, LONG"
+		case op.value() == 0xF002:
+			return "AUDIO"
+		case op.nn() == 0x01:
+			return fmt.Sprintf("PLANE %d", op.x())
+		}
+
+		if format, ok := loads[op.nn()]; ok {
+			return fmt.Sprintf(format, op.x())
+		}
+	}
+
+	return fmt.Sprintf("DW 0x%04X", op.value())
+}
+
+func clcMnemonic(op opcode) string {
+	switch {
+	case op.value() == 0x00E0:
+		return "CLS"
+	case op.value() == 0x00EE:
+		return "RET"
+	case op.value() == 0x00FB:
+		return "SCR"
+	case op.value() == 0x00FC:
+		return "SCL"
+	case op.value() == 0x00FD:
+		return "EXIT"
+	case op.value() == 0x00FE:
+		return "LOW"
+	case op.value() == 0x00FF:
+		return "HIGH"
+	case op.nnn()&0xFF0 == 0x0C0:
+		return fmt.Sprintf("SCD %d", op.n())
+	case op.nnn()&0xFF0 == 0x0D0:
+		return fmt.Sprintf("SCU %d", op.n())
+	}
+
+	return fmt.Sprintf("SYS 0x%03X", op.nnn())
+}
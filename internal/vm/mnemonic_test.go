@@ -0,0 +1,29 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestMnemonic(t *testing.T) {
+	mnemonics := map[uint16]string{
+		0x00E0: "CLS",
+		0x00C4: "SCD 4",
+		0x1234: "JP 0x234",
+		0x3A0F: "SE VA, 0x0F",
+		0x5120: "SE V1, V2",
+		0x5123: "LOAD V1 - V2",
+		0x8126: "SHR V1, V2",
+		0x8128: "DW 0x8128",
+		0xD125: "DRW V1, V2, 5",
+		0xE1A1: "SKNP V1",
+		0xF000: "LD I, LONG",
+		0xF201: "PLANE 2",
+		0xF365: "LD V3, [I]",
+	}
+
+	for value, want := range mnemonics {
+		if got := Mnemonic(value); got != want {
+			t.Errorf("got mnemonic: %q, want mnemonic: %q for %04X\n", got, want, value)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package vm
+
+import (
+	"fmt"
+	"miya/internal/memory"
+	"miya/internal/movie"
+	"sort"
+)
+
+const PLATFORM_VIP = "vip"
+const PLATFORM_ETI660 = "eti-660"
+
+// Platform is the memory layout of a machine: how much memory there is, where the rom is loaded,
+// which fonts are where and how many calls fit on the stack
+type Platform struct {
+	Name             string
+	MemorySize       int
+	LoadAddress      uint16
+	FontAddress      uint16
+	Font             []byte
+	LargeFontAddress uint16
+	LargeFont        []byte
+	StackDepth       int
+}
+
+// NOTE: The VIP keeps 12 return addresses below its display memory, the ETI-660 loads roms at 0x600
+var platforms = map[string]Platform{
+	PLATFORM_CHIP8:  newPlatform(PLATFORM_CHIP8, memory.CHIP8_MEMORY_SIZE, ROM_ADDRESS, FONT_CHIP8, FONT_SCHIP, memory.CHIP8_STACK_SIZE),
+	PLATFORM_VIP:    newPlatform(PLATFORM_VIP, memory.CHIP8_MEMORY_SIZE, ROM_ADDRESS, FONT_VIP, FONT_SCHIP, 12),
+	PLATFORM_ETI660: newPlatform(PLATFORM_ETI660, memory.CHIP8_MEMORY_SIZE, 0x600, FONT_ETI660, FONT_SCHIP, memory.CHIP8_STACK_SIZE),
+	PLATFORM_SCHIP:  newPlatform(PLATFORM_SCHIP, memory.CHIP8_MEMORY_SIZE, ROM_ADDRESS, FONT_CHIP8, FONT_SCHIP, memory.CHIP8_STACK_SIZE),
+	PLATFORM_XOCHIP: newPlatform(PLATFORM_XOCHIP, memory.MAX_MEMORY_SIZE, ROM_ADDRESS, FONT_CHIP8, FONT_XOCHIP, memory.CHIP8_STACK_SIZE),
+}
+
+func newPlatform(name string, size int, load uint16, font, largeFont string, depth int) Platform {
+	return Platform{
+		Name:             name,
+		MemorySize:       size,
+		LoadAddress:      load,
+		FontAddress:      0x000,
+		Font:             smallFonts[font],
+		LargeFontAddress: LARGE_FONT_ADDRESS,
+		LargeFont:        largeFonts[largeFont],
+		StackDepth:       depth,
+	}
+}
+
+func NewPlatform(name string) (Platform, error) {
+	platform, ok := platforms[name]
+	if !ok {
+		return Platform{}, fmt.Errorf("unknown platform: %q, want one of %v", name, PlatformNames())
+	}
+
+	return platform, nil
+}
+
+func PlatformNames() []string {
+	names := make([]string, 0, len(platforms))
+	for name := range platforms {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (platform Platform) Validate() error {
+	if platform.MemorySize < 0x200 || platform.MemorySize > memory.MAX_MEMORY_SIZE {
+		return fmt.Errorf("invalid memory size: %d, want 512 to %d bytes", platform.MemorySize, memory.MAX_MEMORY_SIZE)
+	}
+
+	if platform.StackDepth < 1 && platform.StackDepth != memory.STACK_UNLIMITED {
+		return fmt.Errorf("invalid stack depth: %d, want at least 1 or %d for unlimited", platform.StackDepth, memory.STACK_UNLIMITED)
+	}
+
+	if int(platform.LoadAddress) >= platform.MemorySize {
+		return fmt.Errorf("load address 0x%03x is outside of %d bytes of memory", platform.LoadAddress, platform.MemorySize)
+	}
+
+	small, large := platform.fontRanges()
+	for _, r := range [][2]int{small, large} {
+		if r[1] > platform.MemorySize {
+			return fmt.Errorf("font at 0x%03x does not fit in %d bytes of memory", r[0], platform.MemorySize)
+		}
+
+		if r[0] < int(platform.LoadAddress) && r[1] > int(platform.LoadAddress) {
+			return fmt.Errorf("font at 0x%03x overlaps the rom at 0x%03x", r[0], platform.LoadAddress)
+		}
+	}
+
+	if overlaps(small, large) {
+		return fmt.Errorf("font at 0x%03x overlaps the large font at 0x%03x", platform.FontAddress, platform.LargeFontAddress)
+	}
+
+	return nil
+}
+
+func (platform Platform) Fits(rom []byte) error {
+	if end := int(platform.LoadAddress) + len(rom); end > platform.MemorySize {
+		return fmt.Errorf("rom of %d bytes does not fit at 0x%03x in %d bytes of memory of %s, %d bytes too many", len(rom), platform.LoadAddress, platform.MemorySize, platform.Name, end-platform.MemorySize)
+	}
+
+	small, large := platform.fontRanges()
+	area := [2]int{int(platform.LoadAddress), int(platform.LoadAddress) + len(rom)}
+
+	for _, r := range [][2]int{small, large} {
+		if overlaps(r, area) {
+			return fmt.Errorf("rom of %d bytes at 0x%03x overlaps the font at 0x%03x", len(rom), platform.LoadAddress, r[0])
+		}
+	}
+
+	return nil
+}
+
+func (platform Platform) movie() movie.Platform {
+	return movie.Platform{
+		Name:             platform.Name,
+		MemorySize:       platform.MemorySize,
+		LoadAddress:      platform.LoadAddress,
+		FontAddress:      platform.FontAddress,
+		LargeFontAddress: platform.LargeFontAddress,
+		StackDepth:       platform.StackDepth,
+	}
+}
+
+func (platform Platform) fontRanges() ([2]int, [2]int) {
+	small := [2]int{int(platform.FontAddress), int(platform.FontAddress) + len(platform.Font)}
+	large := [2]int{int(platform.LargeFontAddress), int(platform.LargeFontAddress) + len(platform.LargeFont)}
+
+	return small, large
+}
+
+func overlaps(a, b [2]int) bool {
+	return a[0] < b[1] && b[0] < a[1]
+}
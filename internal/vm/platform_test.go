@@ -0,0 +1,57 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestPlatform_Validate(t *testing.T) {
+	for _, name := range PlatformNames() {
+		platform, err := NewPlatform(name)
+		if err != nil {
+			t.Fatalf("NewPlatform(): %v\n", err)
+		}
+
+		if err := platform.Validate(); err != nil {
+			t.Errorf("got err: %v, want err: nil for %s\n", err, name)
+		}
+	}
+
+	invalid := map[string]func(*Platform){
+		"size":    func(p *Platform) { p.MemorySize = 0x20000 },
+		"load":    func(p *Platform) { p.LoadAddress = 0x1000 },
+		"font":    func(p *Platform) { p.FontAddress = 0xFF0 },
+		"overlap": func(p *Platform) { p.FontAddress = 0x1F0 },
+		"large":   func(p *Platform) { p.LargeFontAddress = 0x040 },
+		"stack":   func(p *Platform) { p.StackDepth = 0 },
+	}
+
+	for name, change := range invalid {
+		platform, _ := NewPlatform(PLATFORM_CHIP8)
+		change(&platform)
+
+		if err := platform.Validate(); err == nil {
+			t.Errorf("got err: nil, want err for %s\n", name)
+		}
+	}
+
+	if _, err := NewPlatform("c64"); err == nil {
+		t.Errorf("got err: nil, want err for an unknown platform\n")
+	}
+}
+
+func TestPlatform_Fits(t *testing.T) {
+	platform, _ := NewPlatform(PLATFORM_ETI660)
+
+	if err := platform.Fits(make([]byte, 0xA00)); err != nil {
+		t.Errorf("got err: %v, want err: nil for 0xA00 bytes at 0x600\n", err)
+	}
+
+	if err := platform.Fits(make([]byte, 0xA01)); err == nil {
+		t.Errorf("got err: nil, want err for 0xA01 bytes at 0x600\n")
+	}
+
+	platform.FontAddress = 0x800
+	if err := platform.Fits(make([]byte, 0x300)); err == nil {
+		t.Errorf("got err: nil, want err for a rom over the font\n")
+	}
+}
@@ -0,0 +1,53 @@
+package vm
+
+import (
+	"fmt"
+	"miya/internal/profile"
+)
+
+var opcodeClasses = map[uint16]string{
+	CLC:       "0NNN",
+	JP:        "1NNN",
+	CALL:      "2NNN",
+	SE_VX:     "3XNN",
+	SNE:       "4XNN",
+	SE_VX_VY:  "5XY0",
+	LD_VX:     "6XNN",
+	ADD:       "7XNN",
+	SNE_VX_VY: "9XY0",
+	LD_I:      "ANNN",
+	JP_V0:     "BNNN",
+	RND:       "CXNN",
+	DRW:       "DXYN",
+}
+
+func (vm *VirtualMachine) SetProfiler(profiler *profile.Profiler) {
+	vm.profiler = profiler
+}
+
+// NOTE: Runs after the instruction, so the depth of the stack already includes the CALL or RET
+func (vm *VirtualMachine) profile(pc uint16, op opcode) {
+	vm.profiler.Step(pc, opcodeClass(op))
+
+	switch {
+	case op.t() == CALL:
+		vm.profiler.Call(pc, op.nnn(), vm.stack.Depth())
+	case op.value() == 0x00EE:
+		vm.profiler.Return(vm.stack.Depth())
+	}
+}
+
+func opcodeClass(op opcode) string {
+	switch {
+	case op.value() == 0x00E0, op.value() == 0x00EE:
+		return fmt.Sprintf("%04X", op.value())
+	case op.t() == VX_VY:
+		return fmt.Sprintf("8XY%X", op.n())
+	case op.t() == SKP:
+		return fmt.Sprintf("EX%02X", op.nn())
+	case op.t() == LDF:
+		return fmt.Sprintf("FX%02X", op.nn())
+	}
+
+	return opcodeClasses[op.t()]
+}
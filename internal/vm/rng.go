@@ -0,0 +1,100 @@
+package vm
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+const RNG_GO = "go"
+const RNG_VIP = "vip"
+
+type RNG interface {
+	Seed(seed int64)
+	Byte() byte
+	Name() string
+}
+
+type GoRNG struct {
+	rand *rand.Rand
+}
+
+// VIPRNG follows the CXNN routine of the COSMAC VIP interpreter. It mixes R9 with a byte
+// from page 0x1XX, where the VIP keeps the second half of the interpreter code
+type VIPRNG struct {
+	r9 uint16
+}
+
+// NOTE: Page 0x1XX of the VIP interpreter, no interpreter is loaded into the memory of the machine, so the
+// routine reads its bytes from here. Reconstructed from the published listing, not yet checked against a dump
+var VIP_INTERPRETER_PAGE = [0x100]byte{
+	0x00, 0x00, 0x45, 0xA3, 0x98, 0x56, 0xD4, 0xF8, 0x81, 0xBC, 0xF8, 0x95, 0xAC, 0x22, 0xDC, 0x12,
+	0x56, 0xD4, 0x06, 0xB8, 0xD4, 0x06, 0xA8, 0xD4, 0x64, 0x0A, 0x01, 0xE6, 0x8A, 0xF4, 0xAA, 0x3B,
+	0x28, 0x9A, 0xFC, 0x01, 0xBA, 0xD4, 0xF8, 0x81, 0xBA, 0x06, 0xFA, 0x0F, 0xAA, 0x0A, 0xAA, 0xD4,
+	0xE6, 0x06, 0xBF, 0x93, 0xBE, 0xF8, 0x1B, 0xAE, 0x2A, 0x1A, 0xF8, 0x00, 0x5A, 0x0E, 0xF5, 0x3B,
+	0x4B, 0x56, 0x0A, 0xFC, 0x01, 0x5A, 0x30, 0x40, 0x4E, 0xF6, 0x3B, 0x3C, 0x9F, 0x56, 0x2A, 0x2A,
+	0xD4, 0x00, 0x22, 0x86, 0x52, 0xF8, 0xF0, 0xA7, 0x07, 0x5A, 0x87, 0xF3, 0x17, 0x1A, 0x3A, 0x5B,
+	0x12, 0xD4, 0x22, 0x86, 0x52, 0xF8, 0xF0, 0xA7, 0x0A, 0x57, 0x87, 0xF3, 0x17, 0x1A, 0x3A, 0x6B,
+	0x12, 0xD4, 0x15, 0x85, 0x22, 0x73, 0x95, 0x52, 0x25, 0x45, 0xA5, 0x86, 0xFA, 0x0F, 0xB5, 0xD4,
+	0x45, 0xE6, 0xF3, 0x3A, 0x82, 0x15, 0x15, 0xD4, 0x45, 0xE6, 0xF3, 0x3A, 0x88, 0xD4, 0x45, 0x07,
+	0x30, 0x8C, 0x45, 0x07, 0x30, 0x84, 0xE6, 0x62, 0x26, 0x45, 0xA3, 0x36, 0x88, 0xD4, 0x3E, 0x88,
+	0xD4, 0xF8, 0xF0, 0xA7, 0xE7, 0x45, 0xF4, 0xA5, 0x86, 0xFA, 0x0F, 0x3B, 0xB2, 0xFC, 0x01, 0xB5,
+	0xD4, 0x45, 0x56, 0xD4, 0x45, 0xE6, 0xF4, 0x56, 0xD4, 0x45, 0xFA, 0x0F, 0x3A, 0xC4, 0x07, 0x56,
+	0xD4, 0xAF, 0x22, 0xF8, 0xD3, 0x73, 0x8F, 0xF9, 0xF0, 0x52, 0xE6, 0x07, 0xD2, 0x56, 0xF8, 0xFF,
+	0xA6, 0xF8, 0x00, 0x7E, 0x56, 0xD4, 0x19, 0x89, 0xAE, 0x93, 0xBE, 0x99, 0xEE, 0xF4, 0x56, 0x76,
+	0xE6, 0xF4, 0xB9, 0x56, 0x45, 0xF2, 0x56, 0xD4, 0x45, 0xAA, 0x86, 0xFA, 0x0F, 0xBA, 0xD4, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xE0, 0x00, 0x4B,
+}
+
+func NewRNG(name string) (RNG, error) {
+	switch name {
+	case RNG_GO:
+		return NewGoRNG(), nil
+	case RNG_VIP:
+		return NewVIPRNG(), nil
+	}
+
+	return nil, fmt.Errorf("unknown rng: %q", name)
+}
+
+func NewGoRNG() *GoRNG {
+	return &GoRNG{rand.New(rand.NewSource(0))}
+}
+
+func (rng *GoRNG) Seed(seed int64) {
+	rng.rand.Seed(seed)
+}
+
+func (rng *GoRNG) Byte() byte {
+	return byte(rng.rand.Intn(0x100))
+}
+
+func (rng *GoRNG) Name() string {
+	return RNG_GO
+}
+
+func NewVIPRNG() *VIPRNG {
+	return &VIPRNG{}
+}
+
+func (rng *VIPRNG) Seed(seed int64) {
+	rng.r9 = uint16(seed)
+}
+
+func (rng *VIPRNG) Byte() byte {
+	rng.r9++
+
+	sum := uint16(rng.r9>>8) + uint16(VIP_INTERPRETER_PAGE[rng.r9&0xFF])
+	vx := byte(sum)
+
+	// SHRC shifts the carry of the ADD into bit 7
+	shifted := (vx >> 1) | byte((sum>>8)<<7)
+	result := shifted + vx
+
+	rng.r9 = uint16(result)<<8 | (rng.r9 & 0xFF)
+
+	return result
+}
+
+func (rng *VIPRNG) Name() string {
+	return RNG_VIP
+}
@@ -0,0 +1,89 @@
+package vm
+
+import (
+	"miya/internal/memory"
+	"miya/internal/screen"
+)
+
+type State struct {
+	registers  registers
+	delayTimer byte
+	soundTimer byte
+	memory     []byte
+	stack      []memory.Frame
+	screen     screen.Frame
+	hires      bool
+	planes     byte
+	frame      uint64
+	overrun    uint64
+	executed   uint64
+	romSize    int
+}
+
+func (vm *VirtualMachine) SaveState() *State {
+	state := State{
+		registers:  vm.registers,
+		delayTimer: vm.delayTimer,
+		soundTimer: vm.soundTimer,
+		memory:     vm.memory.Dump(),
+		stack:      vm.stack.Frames(),
+		screen:     vm.screen.Frame(),
+		hires:      vm.screen.Hires(),
+		planes:     vm.screen.Planes(),
+		frame:      vm.frame,
+		overrun:    vm.overrun,
+		executed:   vm.executed,
+		romSize:    len(vm.rom),
+	}
+
+	state.registers.V = append([]byte(nil), vm.registers.V...)
+
+	return &state
+}
+
+func (vm *VirtualMachine) LoadState(state *State) {
+	vm.registers = state.registers
+	vm.registers.V = append([]byte(nil), state.registers.V...)
+	vm.delayTimer = state.delayTimer
+	vm.soundTimer = state.soundTimer
+	vm.frame = state.frame
+	vm.overrun = state.overrun
+	vm.executed = state.executed
+	vm.waitForKey = false
+	vm.keyEvent = false
+
+	vm.memory.WriteArray(0x000, state.memory)
+
+	vm.stack.Reset()
+	for _, frame := range state.stack {
+		vm.stack.Push(frame)
+	}
+
+	vm.screen.SetFrame(state.screen)
+	vm.screen.SetHires(state.hires)
+	vm.screen.SelectPlanes(state.planes)
+}
+
+// NOTE: The state keeps registers, screen and the memory outside the rom, the new rom is written over it.
+// The part of the old rom past the end of a shorter new one is cleared, it would run as leftover code otherwise
+func (vm *VirtualMachine) Reload(rom []byte, restore bool) error {
+	if err := vm.platform.Fits(rom); err != nil {
+		return err
+	}
+
+	vm.Reset()
+
+	if restore && vm.savedState != nil {
+		vm.LoadState(vm.savedState)
+
+		if tail := vm.savedState.romSize - len(rom); tail > 0 {
+			vm.memory.WriteArray(vm.platform.LoadAddress+uint16(len(rom)), make([]byte, tail))
+		}
+	}
+
+	return vm.LoadROM(rom)
+}
+
+func (vm *VirtualMachine) RequestReload(rom []byte) {
+	vm.reloads <- rom
+}
@@ -0,0 +1,115 @@
+package vm
+
+import (
+	"fmt"
+)
+
+const TIMING_FIXED = "fixed"
+const TIMING_VIP = "vip"
+
+// NOTE: The VIP runs 3668 machine cycles per 60Hz frame, the 1861 display interrupt and its DMA take 1832 of them
+const VIP_FRAME_CYCLES = 3668
+const VIP_INTERRUPT_CYCLES = 1832
+const VIP_FETCH_CYCLES = 40
+
+type Timing interface {
+	Budget(ipf uint64) uint64
+	Cost(op opcode, vx byte, skipped bool) (cycles uint64, wait bool)
+	Name() string
+}
+
+type FixedTiming struct{}
+
+// VIPTiming charges every instruction the machine cycles the COSMAC VIP interpreter spends on it.
+// DXYN waits for the display interrupt, so it is the last instruction of its frame
+type VIPTiming struct{}
+
+func NewTiming(name string) (Timing, error) {
+	switch name {
+	case TIMING_FIXED:
+		return FixedTiming{}, nil
+	case TIMING_VIP:
+		return VIPTiming{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown timing: %q", name)
+}
+
+func (timing FixedTiming) Budget(ipf uint64) uint64 {
+	return ipf
+}
+
+func (timing FixedTiming) Cost(op opcode, vx byte, skipped bool) (uint64, bool) {
+	return 1, false
+}
+
+func (timing FixedTiming) Name() string {
+	return TIMING_FIXED
+}
+
+func (timing VIPTiming) Budget(ipf uint64) uint64 {
+	return VIP_FRAME_CYCLES - VIP_INTERRUPT_CYCLES
+}
+
+func (timing VIPTiming) Cost(op opcode, vx byte, skipped bool) (uint64, bool) {
+	cycles := uint64(VIP_FETCH_CYCLES)
+
+	if skipped {
+		cycles += 4
+	}
+
+	switch op.t() {
+	case CLC:
+		switch op.nnn() {
+		case 0x0E0:
+			cycles += 24 + 3078
+		case 0x0EE:
+			cycles += 10
+		}
+	case JP:
+		cycles += 12
+	case CALL:
+		cycles += 26
+	case SE_VX, SNE, SKP:
+		cycles += 10
+	case SE_VX_VY, SNE_VX_VY:
+		cycles += 14
+	case LD_VX:
+		cycles += 6
+	case ADD:
+		cycles += 10
+	case VX_VY:
+		cycles += 44
+	case LD_I:
+		cycles += 12
+	case JP_V0:
+		cycles += 22
+	case RND:
+		cycles += 36
+	case DRW:
+		// NOTE: Every row is shifted into place one bit at a time, so unaligned sprites cost more
+		cycles += 68 + uint64(op.n())*(46+20*uint64(vx&0x07))
+		return cycles, true
+	case LDF:
+		cycles += ldfCycles(op)
+	}
+
+	return cycles, false
+}
+
+func (timing VIPTiming) Name() string {
+	return TIMING_VIP
+}
+
+func ldfCycles(op opcode) uint64 {
+	switch op.nn() {
+	case 0x1E, 0x29:
+		return 16
+	case 0x33:
+		return 84
+	case 0x55, 0x65:
+		return 14 + 14*uint64(op.x()+1)
+	}
+
+	return 10
+}
@@ -1,7 +1,10 @@
 package vm
 
 import (
+	"miya/internal/coverage"
 	"miya/internal/memory"
+	"miya/internal/movie"
+	"miya/internal/profile"
 	"miya/internal/screen"
 
 	"github.com/veandco/go-sdl2/sdl"
@@ -11,15 +14,45 @@ type VirtualMachine struct {
 	registers    registers
 	delayTimer   byte
 	soundTimer   byte
-	delay        uint64
-	memory       *memory.Memory
-	stack        *memory.Stack
+	ipf          uint64
+	frame        uint64
+	memory       memory.Bus
+	stack        memory.CallStack
 	screen       screen.Chip8Screen
-	instructions map[uint16]func(opcode)
+	instructions [0x10]func(opcode)
 	keys         []byte
-	keyPressed   chan byte
+	input        chan movie.Input
 	waitForKey   bool
+	keyEvent     bool
+	lastKey      byte
 	debugMode    bool
+	seed         int64
+	rng          RNG
+	timing       Timing
+	cycles       uint64
+	overrun      uint64
+	executed     uint64
+	faulted      bool
+	displayWait  bool
+	wrap         bool
+	profiler     *profile.Profiler
+	coverage     *coverage.Coverage
+	access       *memory.AccessMap
+	jit          *JIT
+	warnSMC      bool
+	platform     Platform
+	movieWriter  *movie.Writer
+	replay       *movie.Movie
+	replayPos    int
+	rom          []byte
+	paused       bool
+	fastForward  bool
+	advance      bool
+	savedState   *State
+	reloads      chan []byte
+	watchRestore bool
+	stop         chan struct{}
+	done         chan struct{}
 }
 
 type registers struct {
@@ -28,33 +61,35 @@ type registers struct {
 	V  []byte
 }
 
-type opcode struct {
-	value uint16
-	t     uint16
-	x     byte
-	y     byte
-	n     byte
-	nn    byte
-	nnn   uint16
+// NOTE: The fields are decoded when an instruction reads them, most use one or two of them
+type opcode uint16
+
+func (op opcode) value() uint16 {
+	return uint16(op)
+}
+
+func (op opcode) t() uint16 {
+	return uint16(op) & 0xF000
+}
+
+func (op opcode) x() byte {
+	return byte((op & 0x0F00) >> 8)
+}
+
+func (op opcode) y() byte {
+	return byte((op & 0x00F0) >> 4)
+}
+
+func (op opcode) n() byte {
+	return byte(op & 0x000F)
+}
+
+func (op opcode) nn() byte {
+	return byte(op & 0x00FF)
 }
 
-var font = []byte{
-	0xF0, 0x90, 0x90, 0x90, 0xF0,
-	0x20, 0x60, 0x20, 0x20, 0x70,
-	0xF0, 0x10, 0xF0, 0x80, 0xF0,
-	0xF0, 0x10, 0xF0, 0x10, 0xF0,
-	0x90, 0x90, 0xF0, 0x10, 0x10,
-	0xF0, 0x80, 0xF0, 0x10, 0xF0,
-	0xF0, 0x80, 0xF0, 0x90, 0xF0,
-	0xF0, 0x10, 0x20, 0x40, 0x40,
-	0xF0, 0x90, 0xF0, 0x90, 0xF0,
-	0xF0, 0x90, 0xF0, 0x10, 0xF0,
-	0xF0, 0x90, 0xF0, 0x90, 0x90,
-	0xE0, 0x90, 0xE0, 0x90, 0xE0,
-	0xF0, 0x80, 0x80, 0x80, 0xF0,
-	0xE0, 0x90, 0x90, 0x90, 0xE0,
-	0xF0, 0x80, 0xF0, 0x80, 0xF0,
-	0xF0, 0x80, 0xF0, 0x80, 0x80,
+func (op opcode) nnn() uint16 {
+	return uint16(op) & 0x0FFF
 }
 
 var keymap = map[sdl.Keycode]byte{
@@ -2,94 +2,173 @@ package vm
 
 import (
 	"fmt"
-	"math/rand"
+	"log"
 	"miya/internal/memory"
+	"miya/internal/movie"
+	"miya/internal/rom"
 	"miya/internal/screen"
 	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
 )
 
-func NewVirtualMachine(memory *memory.Memory, stack *memory.Stack, screen screen.Chip8Screen, delay uint64, debugMode bool) *VirtualMachine {
+const INPUT_QUEUE_SIZE = 0x40
+const IPF_STEP = 5
+const FAST_FORWARD_FRAMES = 5
+const STATUS_DURATION = 2 * time.Second
+
+func NewVirtualMachine(memory memory.Bus, stack memory.CallStack, screen screen.Chip8Screen, ipf uint64, debugMode bool) *VirtualMachine {
 	vm := VirtualMachine{
 		registers: registers{
-			PC: 0x200,
+			PC: ROM_ADDRESS,
 			V:  make([]byte, 0x10),
 		},
-		delayTimer:   0,
-		soundTimer:   0,
-		delay:        delay,
-		memory:       memory,
-		stack:        stack,
-		screen:       screen,
-		instructions: make(map[uint16]func(opcode)),
-		keys:         make([]byte, 0x10),
-		keyPressed:   make(chan byte),
-		debugMode:    debugMode,
-	}
-
-	vm.memory.WriteArray(0x000, font)
-
-	vm.instructions[CLC] = vm.clc
-	vm.instructions[JP] = vm.jp
-	vm.instructions[CALL] = vm.call
-	vm.instructions[SE_VX] = vm.sevx
-	vm.instructions[SNE] = vm.sne
-	vm.instructions[SE_VX_VY] = vm.sevxvy
-	vm.instructions[LD_VX] = vm.ldvx
-	vm.instructions[ADD] = vm.add
-	vm.instructions[VX_VY] = vm.vxvy
-	vm.instructions[SNE_VX_VY] = vm.snevxvy
-	vm.instructions[LD_I] = vm.ldi
-	vm.instructions[JP_V0] = vm.jpv0
-	vm.instructions[RND] = vm.rnd
-	vm.instructions[DRW] = vm.drw
-	vm.instructions[SKP] = vm.skp
-	vm.instructions[LDF] = vm.ldf
+		delayTimer: 0,
+		soundTimer: 0,
+		ipf:        ipf,
+		memory:     memory,
+		stack:      stack,
+		screen:     screen,
+		keys:       make([]byte, 0x10),
+		input:      make(chan movie.Input, INPUT_QUEUE_SIZE),
+		reloads:    make(chan []byte, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		debugMode:  debugMode,
+		rng:        NewGoRNG(),
+		timing:     FixedTiming{},
+		platform:   platforms[PLATFORM_CHIP8],
+	}
+
+	vm.SetSeed(time.Now().UnixNano())
+
+	vm.writeFonts()
+
+	// NOTE: The jump table is indexed by the top nibble of the opcode, a map lookup per instruction was most of the cost of Step
+	vm.instructions = [0x10]func(opcode){
+		CLC >> 12:       vm.clc,
+		JP >> 12:        vm.jp,
+		CALL >> 12:      vm.call,
+		SE_VX >> 12:     vm.sevx,
+		SNE >> 12:       vm.sne,
+		SE_VX_VY >> 12:  vm.sevxvy,
+		LD_VX >> 12:     vm.ldvx,
+		ADD >> 12:       vm.add,
+		VX_VY >> 12:     vm.vxvy,
+		SNE_VX_VY >> 12: vm.snevxvy,
+		LD_I >> 12:      vm.ldi,
+		JP_V0 >> 12:     vm.jpv0,
+		RND >> 12:       vm.rnd,
+		DRW >> 12:       vm.drw,
+		SKP >> 12:       vm.skp,
+		LDF >> 12:       vm.ldf,
+	}
 
 	return &vm
 }
 
 func newOpcode(value uint16) opcode {
-	return opcode{
-		value: value,
-		t:     value & 0xF000,
-		x:     byte((value & 0x0F00) >> 8),
-		y:     byte((value & 0x00F0) >> 4),
-		n:     byte(value & 0x000F),
-		nn:    byte(value & 0x00FF),
-		nnn:   value & 0x0FFF,
-	}
+	return opcode(value)
 }
 
 func (op opcode) String() string {
 	return fmt.Sprintf("0x%04x [t: 0x%04x, x: 0x%02x, y: 0x%02x, n: 0x%02x, nn: 0x%02x, nnn: 0x%03x]",
-		op.value,
-		op.t,
-		op.x,
-		op.y,
-		op.n,
-		op.nn,
-		op.nnn)
+		op.value(),
+		op.t(),
+		op.x(),
+		op.y(),
+		op.n(),
+		op.nn(),
+		op.nnn())
 }
 
 func (vm *VirtualMachine) Reset() {
-	vm.registers.PC = 0x200
+	vm.registers.PC = vm.platform.LoadAddress
 	vm.registers.I = 0x000
 	vm.registers.V = make([]byte, 0x10)
 	vm.keys = make([]byte, 0x10)
 	vm.delayTimer = 0
 	vm.soundTimer = 0
+	vm.frame = 0
+	vm.overrun = 0
+	vm.executed = 0
+	vm.faulted = false
+	vm.waitForKey = false
+	vm.keyEvent = false
+	vm.rng.Seed(vm.seed)
 
 	vm.memory.Reset()
 	vm.stack.Reset()
-	vm.screen.Clear()
-	vm.memory.WriteArray(0x000, font)
+
+	if vm.access != nil {
+		vm.access.Reset()
+	}
+
+	vm.screen.SetFrame(screen.Frame{})
+	vm.screen.SelectPlanes(screen.PLANE_DEFAULT)
+	vm.screen.SetHires(false)
+	vm.writeFonts()
+}
+
+func (vm *VirtualMachine) writeFonts() {
+	vm.memory.WriteArray(vm.platform.FontAddress, vm.platform.Font)
+	vm.memory.WriteArray(vm.platform.LargeFontAddress, vm.platform.LargeFont)
+}
+
+func (vm *VirtualMachine) SoftReset() {
+	vm.registers.PC = vm.platform.LoadAddress
+	vm.registers.I = 0x000
+	vm.registers.V = make([]byte, 0x10)
+	vm.delayTimer = 0
+	vm.soundTimer = 0
+	vm.waitForKey = false
+	vm.keyEvent = false
+	vm.faulted = false
+
+	vm.stack.Reset()
+	vm.screen.SetFrame(screen.Frame{})
+	vm.screen.SelectPlanes(screen.PLANE_DEFAULT)
+	vm.screen.SetHires(false)
+}
+
+func (vm *VirtualMachine) HardReset() {
+	vm.Reset()
+	vm.memory.WriteArray(vm.platform.LoadAddress, vm.rom)
+}
+
+func (vm *VirtualMachine) LoadROM(rom []byte) error {
+	if err := vm.platform.Fits(rom); err != nil {
+		return err
+	}
+
+	vm.rom = rom
+
+	return vm.memory.WriteArray(vm.platform.LoadAddress, rom)
+}
+
+// NOTE: The memory and the stack have to be created with the size and the depth of the platform
+func (vm *VirtualMachine) SetPlatform(platform Platform) error {
+	if err := platform.Validate(); err != nil {
+		return err
+	}
+
+	if platform.MemorySize != vm.memory.Size() {
+		return fmt.Errorf("platform %s wants %d bytes of memory, got %d", platform.Name, platform.MemorySize, vm.memory.Size())
+	}
+
+	vm.platform = platform
+	vm.Reset()
+
+	return nil
 }
 
 func (vm *VirtualMachine) Debug() {
 	for {
-		screen.Debug <- fmt.Sprintf("Opcode: %s\nI: 0x%04x\nPC: 0x%04x\nVX: %v\nDelayTimer: %d\nsoundTimer: %d\nKeys: %v\nStack: %v",
+		screen.Debug <- fmt.Sprintf("Frame: %d\nCycles: %d/%d (%s)\nOpcode: %s\nI: 0x%04x\nPC: 0x%04x\nVX: %v\nDelayTimer: %d\nsoundTimer: %d\nKeys: %v\nBacktrace:\n%s",
+			vm.frame,
+			vm.cycles,
+			vm.timing.Budget(vm.ipf),
+			vm.timing.Name(),
 			newOpcode(vm.memory.ReadOpcode(vm.registers.PC)),
 			vm.registers.I,
 			vm.registers.PC,
@@ -97,76 +176,467 @@ func (vm *VirtualMachine) Debug() {
 			vm.delayTimer,
 			vm.soundTimer,
 			vm.keys,
-			vm.stack.Dump())
+			formatBacktrace(vm.Backtrace(), false))
+
+		if vm.access != nil {
+			select {
+			case screen.Heatmap <- vm.access.Heatmap():
+			default:
+			}
+		}
+	}
+}
+
+// NOTE: Instructions run since the last reset, they time the frames of the call stack
+func (vm *VirtualMachine) Executed() uint64 {
+	return vm.executed
+}
+
+func (vm *VirtualMachine) SetSeed(seed int64) {
+	vm.seed = seed
+	vm.rng.Seed(seed)
+}
+
+func (vm *VirtualMachine) SetRNG(rng RNG) {
+	vm.rng = rng
+	vm.rng.Seed(vm.seed)
+}
+
+func (vm *VirtualMachine) SetTiming(timing Timing) {
+	vm.timing = timing
+}
+
+func (vm *VirtualMachine) SetDisplayWait(wait bool) {
+	vm.displayWait = wait
+}
+
+func (vm *VirtualMachine) SetWrap(wrap bool) {
+	vm.wrap = wrap
+}
+
+func (vm *VirtualMachine) RecordMovie(fname string) error {
+	writer, err := movie.Create(fname, movie.Movie{
+		ROM:         rom.SHA1(vm.rom),
+		Seed:        vm.seed,
+		IPF:         vm.ipf,
+		RNG:         vm.rng.Name(),
+		Timing:      vm.timing.Name(),
+		DisplayWait: vm.displayWait,
+		Wrap:        vm.wrap,
+		Platform:    vm.platform.movie(),
+	})
+	if err != nil {
+		return err
 	}
+
+	vm.movieWriter = writer
+
+	return nil
+}
+
+// NOTE: The platform can't be switched here, the memory and the stack were made for it, so a different one is refused
+func (vm *VirtualMachine) ReplayMovie(m *movie.Movie) error {
+	if platform := vm.platform.movie(); m.Platform.Name != "" && m.Platform != platform {
+		return fmt.Errorf("movie was recorded on %+v, running on %+v", m.Platform, platform)
+	}
+
+	// NOTE: The inputs only make sense for the rom they were recorded on, another or an edited rom is refused
+	if sha1 := rom.SHA1(vm.rom); m.ROM != "" && m.ROM != sha1 {
+		return fmt.Errorf("movie was recorded on rom %s, running rom %s", m.ROM, sha1)
+	}
+
+	name := m.RNG
+	if name == "" {
+		name = RNG_GO
+	}
+
+	rng, err := NewRNG(name)
+	if err != nil {
+		return err
+	}
+
+	timingName := m.Timing
+	if timingName == "" {
+		timingName = TIMING_FIXED
+	}
+
+	timing, err := NewTiming(timingName)
+	if err != nil {
+		return err
+	}
+
+	vm.seed = m.Seed
+	vm.SetRNG(rng)
+	vm.SetTiming(timing)
+	vm.SetDisplayWait(m.DisplayWait)
+	vm.SetWrap(m.Wrap)
+	vm.ipf = m.IPF
+	vm.replay = m
+	vm.replayPos = 0
+
+	return nil
 }
 
 func (vm *VirtualMachine) EvalLoop() {
+	defer close(vm.done)
 	go vm.keypad()
 
+	ticker := time.NewTicker(time.Second / screen.FPS)
+	defer ticker.Stop()
+
+	for {
+		vm.handleCommands()
+
+		switch {
+		case vm.paused && vm.advance:
+			vm.RunFrame()
+			vm.advance = false
+		case vm.paused:
+			// NOTE: The keys are still taken while paused, the queue would fill up and hold the keypad goroutine otherwise
+			vm.pollInput()
+		case vm.fastForward:
+			for i := 0; i < FAST_FORWARD_FRAMES; i++ {
+				vm.RunFrame()
+			}
+		default:
+			vm.RunFrame()
+		}
+
+		select {
+		case <-vm.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (vm *VirtualMachine) Stop() {
+	close(vm.stop)
+	<-vm.done
+}
+
+func (vm *VirtualMachine) SetWatchRestore(restore bool) {
+	vm.watchRestore = restore
+}
+
+func (vm *VirtualMachine) handleCommands() {
 	for {
+		select {
+		// NOTE: Resets and loaded states change the screen outside of a frame, so they are published right away for a paused machine
+		case command := <-screen.Commands:
+			vm.command(command)
+			vm.screen.Publish()
+		case rom := <-vm.reloads:
+			// NOTE: A reload resets the machine like a reset does, so it is skipped for movies too
+			if vm.movieWriter != nil || vm.replay != nil {
+				log.Printf("vm.Reload(): skipped, a movie is recorded or replayed\n")
+				screen.SendStatus("Not available while a movie is recorded or replayed", STATUS_DURATION)
+				continue
+			}
+
+			if err := vm.Reload(rom, vm.watchRestore); err != nil {
+				log.Printf("vm.Reload(): %v\n", err)
+				screen.SendStatus("Rom does not fit", STATUS_DURATION)
+				continue
+			}
+
+			vm.screen.Publish()
+			screen.SendStatus("Rom reloaded", STATUS_DURATION)
+		default:
+			return
+		}
+	}
+}
+
+func (vm *VirtualMachine) command(command screen.Command) {
+	switch command {
+	case screen.CMD_PAUSE:
+		vm.paused = !vm.paused
+
+		if vm.paused {
+			screen.SendStatus("Paused", 0)
+		} else {
+			screen.SendStatus("Running", STATUS_DURATION)
+		}
+	case screen.CMD_FRAME_ADVANCE:
+		if !vm.paused {
+			vm.paused = true
+		}
+
+		vm.advance = true
+		screen.SendStatus(fmt.Sprintf("Paused | frame %d", vm.frame+1), 0)
+	case screen.CMD_SAVE_STATE:
+		vm.savedState = vm.SaveState()
+		screen.SendStatus(fmt.Sprintf("State saved at frame %d", vm.frame), STATUS_DURATION)
+	case screen.CMD_FAST_FORWARD:
+		vm.fastForward = !vm.fastForward
+
+		if vm.fastForward {
+			screen.SendStatus(fmt.Sprintf("Fast forward x%d", FAST_FORWARD_FRAMES), 0)
+		} else {
+			screen.SendStatus("Normal speed", STATUS_DURATION)
+		}
+	case screen.CMD_SOFT_RESET, screen.CMD_HARD_RESET, screen.CMD_SPEED_DOWN, screen.CMD_SPEED_UP, screen.CMD_LOAD_STATE:
+		// NOTE: Movies store neither resets nor speed changes, so a replay would diverge from the recording
+		if vm.movieWriter != nil || vm.replay != nil {
+			screen.SendStatus("Not available while a movie is recorded or replayed", STATUS_DURATION)
+			return
+		}
+
+		vm.machineCommand(command)
+	}
+}
+
+func (vm *VirtualMachine) machineCommand(command screen.Command) {
+	switch command {
+	case screen.CMD_SOFT_RESET:
+		vm.SoftReset()
+		screen.SendStatus("Soft reset", STATUS_DURATION)
+	case screen.CMD_HARD_RESET:
+		vm.HardReset()
+		screen.SendStatus("Hard reset", STATUS_DURATION)
+	case screen.CMD_SPEED_DOWN, screen.CMD_SPEED_UP:
+		if vm.timing.Name() != TIMING_FIXED {
+			screen.SendStatus(fmt.Sprintf("Speed is fixed by the %s timing", vm.timing.Name()), STATUS_DURATION)
+			return
+		}
+
+		vm.changeSpeed(command)
+	case screen.CMD_LOAD_STATE:
+		if vm.savedState == nil {
+			screen.SendStatus("No saved state", STATUS_DURATION)
+			return
+		}
+
+		vm.LoadState(vm.savedState)
+		screen.SendStatus(fmt.Sprintf("State loaded from frame %d", vm.frame), STATUS_DURATION)
+	}
+}
+
+func (vm *VirtualMachine) changeSpeed(command screen.Command) {
+	switch command {
+	case screen.CMD_SPEED_DOWN:
+		if vm.ipf > IPF_STEP {
+			vm.ipf -= IPF_STEP
+		} else {
+			vm.ipf = 1
+		}
+
+		screen.SendStatus(fmt.Sprintf("%d ipf", vm.ipf), STATUS_DURATION)
+	case screen.CMD_SPEED_UP:
+		if vm.ipf == 1 {
+			vm.ipf = IPF_STEP
+		} else {
+			vm.ipf += IPF_STEP
+		}
+
+		screen.SendStatus(fmt.Sprintf("%d ipf", vm.ipf), STATUS_DURATION)
+	}
+}
+
+// NOTE: An instruction that runs past the end of the frame is charged to the next one, like on the VIP where the interrupt lands mid instruction
+func (vm *VirtualMachine) RunFrame() {
+	vm.pollInput()
+
+	budget := vm.timing.Budget(vm.ipf)
+	cycles := vm.overrun
+
+	if vm.jitEnabled() {
+		cycles = vm.jit.run(cycles, budget)
+	}
+
+	for cycles < budget {
 		if vm.debugMode {
 			<-screen.Next
 		}
 
-		opcode := newOpcode(vm.memory.ReadOpcode(vm.registers.PC))
-		vm.instructions[opcode.t](opcode)
+		pc := vm.registers.PC
+		op := newOpcode(vm.memory.ReadOpcode(pc))
+		vx := vm.registers.V[op.x()]
 
-		if vm.delayTimer > 0 {
-			vm.delayTimer--
+		if vm.access != nil {
+			vm.execute(pc)
 		}
 
-		if vm.soundTimer > 0 {
-			vm.soundTimer--
+		vm.exec(op)
+
+		if vm.profiler != nil {
+			vm.profile(pc, op)
 		}
 
-		time.Sleep(time.Millisecond * time.Duration(vm.delay))
+		if vm.coverage != nil {
+			vm.cover(pc, op)
+		}
+
+		cycles = vm.charge(cycles, budget, op, vx, pc)
+
+		// NOTE: The faulting instruction would run again and again, the rest of the frame is skipped
+		if vm.faulted && cycles < budget {
+			cycles = budget
+		}
+	}
+
+	vm.cycles = cycles
+	vm.overrun = cycles - budget
+	vm.screen.Publish()
+
+	if vm.profiler != nil {
+		vm.profiler.EndFrame()
+	}
+
+	if vm.access != nil {
+		vm.access.Decay()
+	}
+
+	if vm.delayTimer > 0 {
+		vm.delayTimer--
 	}
+
+	if vm.soundTimer > 0 {
+		vm.soundTimer--
+	}
+
+	vm.frame++
 }
 
-func (vm *VirtualMachine) keypad() {
+func (vm *VirtualMachine) charge(cycles, budget uint64, op opcode, vx byte, pc uint16) uint64 {
+	// NOTE: A jump or a call can land two instructions ahead too, only a skip instruction skips
+	cost, wait := vm.timing.Cost(op, vx, skips(op) && vm.registers.PC == pc+4)
+	cycles += cost
+
+	if (wait || (vm.displayWait && op.t() == DRW)) && cycles < budget {
+		cycles = budget
+	}
+
+	return cycles
+}
+
+func (vm *VirtualMachine) Step() {
+	vm.exec(newOpcode(vm.memory.ReadOpcode(vm.registers.PC)))
+}
+
+func (vm *VirtualMachine) exec(opcode opcode) {
+	vm.instructions[opcode.value()>>12](opcode)
+	vm.executed++
+}
+
+// NOTE: Inputs are only applied between frames, so a recorded movie replays into exactly the same state
+func (vm *VirtualMachine) pollInput() {
+	if vm.replay != nil {
+		vm.pollReplay()
+		return
+	}
+
 	for {
-		keyevent := <-screen.KeyPressed
+		select {
+		case input := <-vm.input:
+			input.Frame = vm.frame
+
+			if vm.applyInput(input) && vm.movieWriter != nil {
+				if err := vm.movieWriter.WriteInput(input); err != nil {
+					log.Printf("vm.movieWriter.WriteInput(): %v\n", err)
+					vm.movieWriter = nil
+				}
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (vm *VirtualMachine) pollReplay() {
+	for len(vm.input) > 0 {
+		<-vm.input
+	}
+
+	for ; vm.replayPos < len(vm.replay.Inputs) && vm.replay.Inputs[vm.replayPos].Frame <= vm.frame; vm.replayPos++ {
+		vm.applyInput(vm.replay.Inputs[vm.replayPos])
+	}
+
+	if vm.replayPos == len(vm.replay.Inputs) {
+		log.Printf("Replay finished at frame %d\n", vm.frame)
+		vm.replay = nil
+	}
+}
 
-		if _, ok := keymap[keyevent.Keycode]; ok {
-			if keyevent.Etype == sdl.KEYUP {
-				vm.keys[keymap[keyevent.Keycode]] = 0
-			} else {
-				vm.keys[keymap[keyevent.Keycode]] = 1
+func (vm *VirtualMachine) applyInput(input movie.Input) bool {
+	state := byte(0)
+	if input.Pressed {
+		state = 1
+	}
+
+	if vm.keys[input.Key] == state {
+		return false
+	}
 
-				if vm.waitForKey {
-					vm.keyPressed <- keymap[keyevent.Keycode]
+	vm.keys[input.Key] = state
+
+	if input.Pressed && vm.waitForKey {
+		vm.lastKey = input.Key
+		vm.keyEvent = true
+	}
+
+	return true
+}
+
+func (vm *VirtualMachine) keypad() {
+	for {
+		select {
+		case keyevent := <-screen.KeyPressed:
+			if key, ok := keymap[keyevent.Keycode]; ok {
+				select {
+				case vm.input <- movie.Input{Key: key, Pressed: keyevent.Etype != sdl.KEYUP}:
+				case <-vm.stop:
+					return
 				}
 			}
+		case <-vm.stop:
+			return
 		}
 	}
 }
 
 func (vm *VirtualMachine) clc(opcode opcode) {
-	if opcode.nnn == 0x0E0 {
+	if opcode.nnn() == 0x0E0 {
 		vm.screen.Clear()
 		vm.registers.PC += 2
 
 		return
 	}
 
-	if opcode.nnn == 0x0EE {
-		vm.registers.PC = vm.stack.Pop()
+	if opcode.nnn() == 0x0FE || opcode.nnn() == 0x0FF {
+		vm.screen.SetHires(opcode.nnn() == 0x0FF)
 		vm.registers.PC += 2
+
+		return
+	}
+
+	if opcode.nnn() == 0x0EE {
+		frame, err := vm.stack.Pop()
+		if err != nil {
+			vm.fault(err)
+			return
+		}
+
+		vm.registers.PC = frame.Caller + 2
 	}
 }
 
 func (vm *VirtualMachine) jp(opcode opcode) {
-	vm.registers.PC = opcode.nnn
+	vm.registers.PC = opcode.nnn()
 }
 
 func (vm *VirtualMachine) call(opcode opcode) {
-	vm.stack.Push(vm.registers.PC)
-	vm.registers.PC = opcode.nnn
+	if err := vm.stack.Push(vm.newFrame(opcode.nnn())); err != nil {
+		vm.fault(err)
+		return
+	}
+
+	vm.registers.PC = opcode.nnn()
 }
 
 func (vm *VirtualMachine) sevx(opcode opcode) {
-	if vm.registers.V[opcode.x] == opcode.nn {
+	if vm.registers.V[opcode.x()] == opcode.nn() {
 		vm.registers.PC += 4
 		return
 	}
@@ -175,7 +645,7 @@ func (vm *VirtualMachine) sevx(opcode opcode) {
 }
 
 func (vm *VirtualMachine) sne(opcode opcode) {
-	if vm.registers.V[opcode.x] != opcode.nn {
+	if vm.registers.V[opcode.x()] != opcode.nn() {
 		vm.registers.PC += 4
 		return
 	}
@@ -184,7 +654,7 @@ func (vm *VirtualMachine) sne(opcode opcode) {
 }
 
 func (vm *VirtualMachine) sevxvy(opcode opcode) {
-	if vm.registers.V[opcode.x] == vm.registers.V[opcode.y] {
+	if vm.registers.V[opcode.x()] == vm.registers.V[opcode.y()] {
 		vm.registers.PC += 4
 		return
 	}
@@ -193,62 +663,62 @@ func (vm *VirtualMachine) sevxvy(opcode opcode) {
 }
 
 func (vm *VirtualMachine) ldvx(opcode opcode) {
-	vm.registers.V[opcode.x] = opcode.nn
+	vm.registers.V[opcode.x()] = opcode.nn()
 	vm.registers.PC += 2
 }
 
 func (vm *VirtualMachine) add(opcode opcode) {
-	vm.registers.V[opcode.x] += opcode.nn
+	vm.registers.V[opcode.x()] += opcode.nn()
 	vm.registers.PC += 2
 }
 
 func (vm *VirtualMachine) vxvy(opcode opcode) {
-	switch opcode.n {
+	switch opcode.n() {
 	case 0:
-		vm.registers.V[opcode.x] = vm.registers.V[opcode.y]
+		vm.registers.V[opcode.x()] = vm.registers.V[opcode.y()]
 	case 1:
-		vm.registers.V[opcode.x] |= vm.registers.V[opcode.y]
+		vm.registers.V[opcode.x()] |= vm.registers.V[opcode.y()]
 	case 2:
-		vm.registers.V[opcode.x] &= vm.registers.V[opcode.y]
+		vm.registers.V[opcode.x()] &= vm.registers.V[opcode.y()]
 	case 3:
-		vm.registers.V[opcode.x] ^= vm.registers.V[opcode.y]
+		vm.registers.V[opcode.x()] ^= vm.registers.V[opcode.y()]
 	case 4:
-		if (uint16(vm.registers.V[opcode.x]) + uint16(vm.registers.V[opcode.y])) > 0xFF {
+		if (uint16(vm.registers.V[opcode.x()]) + uint16(vm.registers.V[opcode.y()])) > 0xFF {
 			vm.registers.V[0x0F] = 1
 		} else {
 			vm.registers.V[0x0F] = 0
 		}
 
-		vm.registers.V[opcode.x] += vm.registers.V[opcode.y]
+		vm.registers.V[opcode.x()] += vm.registers.V[opcode.y()]
 	case 5:
-		if vm.registers.V[opcode.x] > vm.registers.V[opcode.y] {
+		if vm.registers.V[opcode.x()] > vm.registers.V[opcode.y()] {
 			vm.registers.V[0x0F] = 1
 		} else {
 			vm.registers.V[0x0F] = 0
 		}
 
-		vm.registers.V[opcode.x] -= vm.registers.V[opcode.y]
+		vm.registers.V[opcode.x()] -= vm.registers.V[opcode.y()]
 	case 6:
-		vm.registers.V[0x0F] = (vm.registers.V[opcode.x] & 0x01)
-		vm.registers.V[opcode.x] >>= 1
+		vm.registers.V[0x0F] = (vm.registers.V[opcode.x()] & 0x01)
+		vm.registers.V[opcode.x()] >>= 1
 	case 7:
-		if vm.registers.V[opcode.y] > vm.registers.V[opcode.x] {
+		if vm.registers.V[opcode.y()] > vm.registers.V[opcode.x()] {
 			vm.registers.V[0x0F] = 1
 		} else {
 			vm.registers.V[0x0F] = 0
 		}
 
-		vm.registers.V[opcode.x] = vm.registers.V[opcode.y] - vm.registers.V[opcode.x]
+		vm.registers.V[opcode.x()] = vm.registers.V[opcode.y()] - vm.registers.V[opcode.x()]
 	case 0xe:
-		vm.registers.V[0x0F] = (vm.registers.V[opcode.x] & 0x80)
-		vm.registers.V[opcode.x] <<= 1
+		vm.registers.V[0x0F] = (vm.registers.V[opcode.x()] & 0x80)
+		vm.registers.V[opcode.x()] <<= 1
 	}
 
 	vm.registers.PC += 2
 }
 
 func (vm *VirtualMachine) snevxvy(opcode opcode) {
-	if vm.registers.V[opcode.x] != vm.registers.V[opcode.y] {
+	if vm.registers.V[opcode.x()] != vm.registers.V[opcode.y()] {
 		vm.registers.PC += 4
 		return
 	}
@@ -257,52 +727,132 @@ func (vm *VirtualMachine) snevxvy(opcode opcode) {
 }
 
 func (vm *VirtualMachine) ldi(opcode opcode) {
-	vm.registers.I = opcode.nnn
+	vm.registers.I = opcode.nnn()
 	vm.registers.PC += 2
 }
 
 func (vm *VirtualMachine) jpv0(opcode opcode) {
-	vm.registers.PC = uint16(vm.registers.V[0]) + opcode.nnn
+	vm.registers.PC = uint16(vm.registers.V[0]) + opcode.nnn()
 }
 
 func (vm *VirtualMachine) rnd(opcode opcode) {
-	rand.Seed(time.Now().UnixNano())
-	vm.registers.V[opcode.x] = byte(rand.Intn(0xFF)) & opcode.nn
+	vm.registers.V[opcode.x()] = vm.rng.Byte() & opcode.nn()
 	vm.registers.PC += 2
 }
 
+// NOTE: The start coordinates always wrap, the pixels past the edges are clipped unless vm.wrap is set.
+// DXY0 draws a 16x16 sprite of 2 bytes per row, and in hires VF counts the rows that collided or were
+// clipped at the bottom like on SCHIP 1.1. With more than one plane selected the sprite is drawn once per plane,
+// the data of every plane follows the one before it like on XO-CHIP
 func (vm *VirtualMachine) drw(opcode opcode) {
-	x := vm.registers.V[opcode.x]
-	y := vm.registers.V[opcode.y]
+	planes := vm.screen.Planes()
+
+	size := uint16(opcode.n())
+	if opcode.n() == 0 {
+		size = 32
+	}
+
+	collisions := byte(0)
+	addr := vm.registers.I
+
+	for plane := byte(1); plane <= screen.PLANE_ALL; plane <<= 1 {
+		if planes&plane == 0 {
+			continue
+		}
+
+		vm.screen.SelectPlanes(plane)
+		collisions += vm.sprite(opcode, addr)
+		addr += size
+	}
+
+	vm.screen.SelectPlanes(planes)
+
+	if !vm.screen.Hires() && collisions > 1 {
+		collisions = 1
+	}
 
-	vm.registers.V[0x0F] = 0
+	vm.registers.V[0x0F] = collisions
+	vm.registers.PC += 2
+}
+
+func (vm *VirtualMachine) sprite(opcode opcode, addr uint16) byte {
+	width, height := vm.screenSize()
+	x := int(vm.registers.V[opcode.x()]) % width
+	y := int(vm.registers.V[opcode.y()]) % height
+
+	rows, cols := uint16(opcode.n()), 8
+	if opcode.n() == 0 {
+		rows, cols = 16, 16
+	}
+
+	collisions := byte(0)
+
+	for i := uint16(0); i < rows; i++ {
+		line := uint16(vm.read(addr+i)) << 8
+		if cols == 16 {
+			line = uint16(vm.read(addr+2*i))<<8 | uint16(vm.read(addr+2*i+1))
+		}
+
+		py := y + int(i)
+		if py >= height && !vm.wrap {
+			if vm.screen.Hires() {
+				collisions++
+			}
+
+			continue
+		}
+
+		py %= height
+		collided := false
+
+		for k := 0; k < cols; k++ {
+			if line&(0x8000>>k) == 0 {
+				continue
+			}
 
-	for i := uint16(0); i < uint16(opcode.n); i++ {
-		pixel := vm.memory.Read(vm.registers.I + i)
-		for k := 0; k < 8; k++ {
-			if pixel&(0x80>>k) != 0 {
-				if vm.screen.GetPixel(x+byte(k), y+byte(i)) == 1 {
-					vm.registers.V[0x0F] = 1
+			px := x + k
+			if px >= width {
+				if !vm.wrap {
+					continue
 				}
 
-				vm.screen.SetPixel(x+byte(k), y+byte(i))
+				px %= width
+			}
+
+			if vm.screen.GetPixel(byte(px), byte(py)) == 1 {
+				vm.screen.Collide(byte(px), byte(py))
+				collided = true
 			}
+
+			vm.screen.SetPixel(byte(px), byte(py))
+		}
+
+		if collided {
+			collisions++
 		}
 	}
 
-	vm.registers.PC += 2
+	return collisions
+}
+
+func (vm *VirtualMachine) screenSize() (int, int) {
+	if vm.screen.Hires() {
+		return screen.WIDTH, screen.HEIGHT
+	}
+
+	return screen.LORES_WIDTH, screen.LORES_HEIGHT
 }
 
 func (vm *VirtualMachine) skp(opcode opcode) {
-	if opcode.nn == 0x9E {
-		if vm.keys[vm.registers.V[opcode.x]] == 1 {
+	if opcode.nn() == 0x9E {
+		if vm.keys[vm.registers.V[opcode.x()]] == 1 {
 			vm.registers.PC += 4
 			return
 		}
 	}
 
-	if opcode.nn == 0xA1 {
-		if vm.keys[vm.registers.V[opcode.x]] == 0 {
+	if opcode.nn() == 0xA1 {
+		if vm.keys[vm.registers.V[opcode.x()]] == 0 {
 			vm.registers.PC += 4
 			return
 		}
@@ -312,34 +862,45 @@ func (vm *VirtualMachine) skp(opcode opcode) {
 }
 
 func (vm *VirtualMachine) ldf(opcode opcode) {
-	switch opcode.nn {
+	switch opcode.nn() {
+	case 0x01:
+		// NOTE: FN01 of XO-CHIP, the X nibble is the mask of the planes that are drawn and cleared
+		vm.screen.SelectPlanes(opcode.x())
 	case 0x07:
-		vm.registers.V[opcode.x] = vm.delayTimer
+		vm.registers.V[opcode.x()] = vm.delayTimer
 	case 0x0A:
-		vm.waitForKey = true
-		vm.registers.V[opcode.x] = <-vm.keyPressed
+		// NOTE: Without a key press the instruction repeats, so the frame keeps running and inputs can arrive
+		if !vm.keyEvent {
+			vm.waitForKey = true
+			return
+		}
+
+		vm.registers.V[opcode.x()] = vm.lastKey
 		vm.waitForKey = false
+		vm.keyEvent = false
 	case 0x15:
-		vm.delayTimer = vm.registers.V[opcode.x]
+		vm.delayTimer = vm.registers.V[opcode.x()]
 	case 0x18:
-		vm.soundTimer = vm.registers.V[opcode.x]
+		vm.soundTimer = vm.registers.V[opcode.x()]
 	case 0x1E:
-		vm.registers.I += uint16(vm.registers.V[opcode.x])
+		vm.registers.I += uint16(vm.registers.V[opcode.x()])
 	case 0x29:
-		vm.registers.I = uint16(vm.registers.V[opcode.x] * 0x05)
+		vm.registers.I = vm.platform.FontAddress + uint16(vm.registers.V[opcode.x()]&0x0F)*SMALL_GLYPH_SIZE
+	case 0x30:
+		vm.registers.I = vm.platform.LargeFontAddress + uint16(vm.registers.V[opcode.x()]&0x0F)*LARGE_GLYPH_SIZE
 	case 0x33:
-		n := vm.registers.V[opcode.x]
-		vm.memory.Write(vm.registers.I, n/100)
-		vm.memory.Write(vm.registers.I+1, (n/10)%10)
-		vm.memory.Write(vm.registers.I+2, (n%100)%10)
+		n := vm.registers.V[opcode.x()]
+		vm.write(vm.registers.I, n/100)
+		vm.write(vm.registers.I+1, (n/10)%10)
+		vm.write(vm.registers.I+2, (n%100)%10)
 	case 0x55:
-		for i := byte(0); i <= opcode.x; i++ {
-			vm.memory.Write(vm.registers.I, vm.registers.V[i])
+		for i := byte(0); i <= opcode.x(); i++ {
+			vm.write(vm.registers.I, vm.registers.V[i])
 			vm.registers.I += 1
 		}
 	case 0x65:
-		for i := byte(0); i <= opcode.x; i++ {
-			vm.registers.V[i] = vm.memory.Read(vm.registers.I)
+		for i := byte(0); i <= opcode.x(); i++ {
+			vm.registers.V[i] = vm.read(vm.registers.I)
 			vm.registers.I += 1
 		}
 	}
@@ -8,15 +8,37 @@ import (
 
 var vm *VirtualMachine
 
+// NOTE: The test machine runs on a WatchBus without watchpoints, so a test can watch an address and check the hits
+var bus *memory.WatchBus
+var hits []memory.WatchHit
+
+type fixedRNG struct {
+	value byte
+}
+
 type testCase struct {
 	test *testing.T
 	name string
 }
 
 func init() {
-	mem := memory.NewMemory(memory.CHIP8_MEMORY_SIZE)
-	stc := memory.NewStack(memory.CHIP8_STACK_SIZE)
-	vm = NewVirtualMachine(mem, stc, &screen.MockWindow{}, 10, false)
+	var stc memory.CallStack = memory.NewStack(memory.CHIP8_STACK_SIZE)
+
+	bus = memory.NewWatchBus(memory.NewMemory(memory.CHIP8_MEMORY_SIZE), func(hit memory.WatchHit) {
+		hits = append(hits, hit)
+	})
+
+	vm = NewVirtualMachine(bus, stc, &screen.MockWindow{}, 10, false)
+}
+
+func (rng *fixedRNG) Seed(seed int64) {}
+
+func (rng *fixedRNG) Byte() byte {
+	return rng.value
+}
+
+func (rng *fixedRNG) Name() string {
+	return "fixed"
 }
 
 func newTestCase(test *testing.T, name string) testCase {
@@ -43,9 +65,9 @@ func (tcase testCase) assertEqualScreen(value [64][32]byte) {
 }
 
 func (tcase testCase) assertEqualStackHead(value uint16) {
-	head := vm.stack.Pop()
+	frame, _ := vm.stack.Pop()
 
-	if head != value {
+	if head := frame.Caller; head != value {
 		tcase.test.Errorf("[%s] got stack.pop(): 0x%04x, want stack.pop(): 0x%04x\n", tcase.name, head, value)
 	}
 }
@@ -91,3 +113,23 @@ func (tcase testCase) assertEqualMemory(addr uint16, value byte) {
 		tcase.test.Errorf("[%s] got memory[0x%04x]: 0x%04x, want memory[0x%04x]: 0x%04x\n", tcase.name, addr, vm.memory.Read(addr), addr, value)
 	}
 }
+
+func (tcase testCase) assertEqualFrame(value uint64) {
+	if vm.frame != value {
+		tcase.test.Errorf("[%s] got Frame: %d, want Frame: %d\n", tcase.name, vm.frame, value)
+	}
+}
+
+func (tcase testCase) assertEqualHits(value []memory.WatchHit) {
+	if len(hits) != len(value) {
+		tcase.test.Errorf("[%s] got hits: %v, want hits: %v\n", tcase.name, hits, value)
+		return
+	}
+
+	for i := range hits {
+		if hits[i] != value[i] {
+			tcase.test.Errorf("[%s] got hits: %v, want hits: %v\n", tcase.name, hits, value)
+			return
+		}
+	}
+}
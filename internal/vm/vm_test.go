@@ -1,9 +1,16 @@
 package vm
 
 import (
+	"bytes"
+	"log"
+	"miya/internal/coverage"
+	"miya/internal/memory"
+	"miya/internal/movie"
+	"miya/internal/profile"
+	"miya/internal/rom"
+	"miya/internal/screen"
+	"strings"
 	"testing"
-
-	"github.com/veandco/go-sdl2/sdl"
 )
 
 func TestReset(t *testing.T) {
@@ -51,7 +58,7 @@ func TestClc_EE(t *testing.T) {
 	opcode := newOpcode(0x00EE)
 	tcase := newTestCase(t, "CLC 0x00EE")
 
-	vm.stack.Push(vm.registers.PC)
+	vm.stack.Push(memory.Frame{Caller: vm.registers.PC, Callee: 0x255})
 	vm.registers.PC = 0x255
 
 	vm.clc(opcode)
@@ -65,7 +72,7 @@ func TestJp(t *testing.T) {
 	tcase := newTestCase(t, "JP")
 
 	vm.jp(opcode)
-	tcase.assertEqualPC(opcode.nnn)
+	tcase.assertEqualPC(opcode.nnn())
 
 	vm.Reset()
 }
@@ -76,7 +83,7 @@ func TestCall(t *testing.T) {
 
 	vm.call(opcode)
 	tcase.assertEqualStackHead(0x200)
-	tcase.assertEqualPC(opcode.nnn)
+	tcase.assertEqualPC(opcode.nnn())
 
 	vm.Reset()
 }
@@ -85,7 +92,7 @@ func TestSevx_skip(t *testing.T) {
 	opcode := newOpcode(0x3ABC)
 	tcase := newTestCase(t, "SEVX skip")
 
-	vm.registers.V[opcode.x] = opcode.nn
+	vm.registers.V[opcode.x()] = opcode.nn()
 
 	vm.sevx(opcode)
 	tcase.assertEqualPC(0x204)
@@ -117,7 +124,7 @@ func TestSne(t *testing.T) {
 	opcode := newOpcode(0x4ABC)
 	tcase := newTestCase(t, "SNE")
 
-	vm.registers.V[opcode.x] = opcode.nn
+	vm.registers.V[opcode.x()] = opcode.nn()
 
 	vm.sne(opcode)
 	tcase.assertEqualPC(0x202)
@@ -129,8 +136,8 @@ func TestSevxvy_skip(t *testing.T) {
 	opcode := newOpcode(0x5ABC)
 	tcase := newTestCase(t, "SEVXVY skip")
 
-	vm.registers.V[opcode.x] = 0x0A
-	vm.registers.V[opcode.y] = 0x0A
+	vm.registers.V[opcode.x()] = 0x0A
+	vm.registers.V[opcode.y()] = 0x0A
 
 	vm.sevxvy(opcode)
 	tcase.assertEqualPC(0x204)
@@ -142,8 +149,8 @@ func TestSevxvy(t *testing.T) {
 	opcode := newOpcode(0x5ABC)
 	tcase := newTestCase(t, "SEVXVY")
 
-	vm.registers.V[opcode.x] = 0x00
-	vm.registers.V[opcode.y] = 0x0A
+	vm.registers.V[opcode.x()] = 0x00
+	vm.registers.V[opcode.y()] = 0x0A
 
 	vm.sevxvy(opcode)
 	tcase.assertEqualPC(0x202)
@@ -156,7 +163,7 @@ func TestLdvx(t *testing.T) {
 	tcase := newTestCase(t, "LDVX")
 
 	vm.ldvx(opcode)
-	tcase.assertEqualVx(opcode.x, opcode.nn)
+	tcase.assertEqualVx(opcode.x(), opcode.nn())
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -167,7 +174,7 @@ func TestAdd(t *testing.T) {
 	tcase := newTestCase(t, "ADD")
 
 	vm.add(opcode)
-	tcase.assertEqualVx(opcode.x, opcode.nn)
+	tcase.assertEqualVx(opcode.x(), opcode.nn())
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -177,10 +184,10 @@ func TestVxvy_0(t *testing.T) {
 	opcode := newOpcode(0x8AB0)
 	tcase := newTestCase(t, "VXVY 0x00")
 
-	vm.registers.V[opcode.y] = 0x0A
+	vm.registers.V[opcode.y()] = 0x0A
 
 	vm.vxvy(opcode)
-	tcase.assertEqualVx(opcode.x, 0x0A)
+	tcase.assertEqualVx(opcode.x(), 0x0A)
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -190,10 +197,10 @@ func TestVxvy_1(t *testing.T) {
 	opcode := newOpcode(0x8AB1)
 	tcase := newTestCase(t, "VXVY 0x01")
 
-	vm.registers.V[opcode.y] = 0x0A
+	vm.registers.V[opcode.y()] = 0x0A
 
 	vm.vxvy(opcode)
-	tcase.assertEqualVx(opcode.x, (0x00 | 0x0A))
+	tcase.assertEqualVx(opcode.x(), (0x00 | 0x0A))
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -203,10 +210,10 @@ func TestVxvy_2(t *testing.T) {
 	opcode := newOpcode(0x8AB2)
 	tcase := newTestCase(t, "VXVY 0x02")
 
-	vm.registers.V[opcode.y] = 0x0A
+	vm.registers.V[opcode.y()] = 0x0A
 
 	vm.vxvy(opcode)
-	tcase.assertEqualVx(opcode.x, (0x00 & 0x0A))
+	tcase.assertEqualVx(opcode.x(), (0x00 & 0x0A))
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -216,10 +223,10 @@ func TestVxvy_3(t *testing.T) {
 	opcode := newOpcode(0x8AB3)
 	tcase := newTestCase(t, "VXVY 0x03")
 
-	vm.registers.V[opcode.y] = 0x0A
+	vm.registers.V[opcode.y()] = 0x0A
 
 	vm.vxvy(opcode)
-	tcase.assertEqualVx(opcode.x, (0x00 ^ 0x0A))
+	tcase.assertEqualVx(opcode.x(), (0x00 ^ 0x0A))
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -229,12 +236,12 @@ func TestVxvy_4_carry(t *testing.T) {
 	opcode := newOpcode(0x8AB4)
 	tcase := newTestCase(t, "VXVY 0x04 carry flag")
 
-	vm.registers.V[opcode.x] = 0xFF
-	vm.registers.V[opcode.y] = 0x0A
+	vm.registers.V[opcode.x()] = 0xFF
+	vm.registers.V[opcode.y()] = 0x0A
 
 	vm.vxvy(opcode)
 	tcase.assertEqualVx(0x0F, 0x01)
-	tcase.assertEqualVx(opcode.x, ((0xFF + 0x0A) & 0xFF))
+	tcase.assertEqualVx(opcode.x(), ((0xFF + 0x0A) & 0xFF))
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -244,12 +251,12 @@ func TestVxvy_4(t *testing.T) {
 	opcode := newOpcode(0x8AB4)
 	tcase := newTestCase(t, "VXVY 0x04")
 
-	vm.registers.V[opcode.x] = 0x0A
-	vm.registers.V[opcode.y] = 0x0A
+	vm.registers.V[opcode.x()] = 0x0A
+	vm.registers.V[opcode.y()] = 0x0A
 
 	vm.vxvy(opcode)
 	tcase.assertEqualVx(0x0F, 0x00)
-	tcase.assertEqualVx(opcode.x, (0x0A + 0x0A))
+	tcase.assertEqualVx(opcode.x(), (0x0A + 0x0A))
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -259,12 +266,12 @@ func TestVxvy_5_carry(t *testing.T) {
 	opcode := newOpcode(0x8AB5)
 	tcase := newTestCase(t, "VXVY 0x05 carry flag")
 
-	vm.registers.V[opcode.x] = 0x10
-	vm.registers.V[opcode.y] = 0x05
+	vm.registers.V[opcode.x()] = 0x10
+	vm.registers.V[opcode.y()] = 0x05
 
 	vm.vxvy(opcode)
 	tcase.assertEqualVx(0x0F, 0x01)
-	tcase.assertEqualVx(opcode.x, (0x10 - 0x05))
+	tcase.assertEqualVx(opcode.x(), (0x10 - 0x05))
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -274,12 +281,12 @@ func TestVxvy_5(t *testing.T) {
 	opcode := newOpcode(0x8AB5)
 	tcase := newTestCase(t, "VXVY 0x05")
 
-	vm.registers.V[opcode.x] = 0x05
-	vm.registers.V[opcode.y] = 0x10
+	vm.registers.V[opcode.x()] = 0x05
+	vm.registers.V[opcode.y()] = 0x10
 
 	vm.vxvy(opcode)
 	tcase.assertEqualVx(0x0F, 0x00)
-	tcase.assertEqualVx(opcode.x, ((0x05 - 0x10) & 0xFF))
+	tcase.assertEqualVx(opcode.x(), ((0x05 - 0x10) & 0xFF))
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -289,11 +296,11 @@ func TestVxvy_6(t *testing.T) {
 	opcode := newOpcode(0x8AB6)
 	tcase := newTestCase(t, "VXVY 0x06")
 
-	vm.registers.V[opcode.x] = 0x10
+	vm.registers.V[opcode.x()] = 0x10
 
 	vm.vxvy(opcode)
 	tcase.assertEqualVx(0x0F, 0x00)
-	tcase.assertEqualVx(opcode.x, (0x10 >> 1))
+	tcase.assertEqualVx(opcode.x(), (0x10 >> 1))
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -303,12 +310,12 @@ func TestVxvy_7_carry(t *testing.T) {
 	opcode := newOpcode(0x8AB7)
 	tcase := newTestCase(t, "VXVY 0x07 carry flag")
 
-	vm.registers.V[opcode.x] = 0x0A
-	vm.registers.V[opcode.y] = 0xFF
+	vm.registers.V[opcode.x()] = 0x0A
+	vm.registers.V[opcode.y()] = 0xFF
 
 	vm.vxvy(opcode)
 	tcase.assertEqualVx(0x0F, 0x01)
-	tcase.assertEqualVx(opcode.x, (0xFF - 0x0A))
+	tcase.assertEqualVx(opcode.x(), (0xFF - 0x0A))
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -318,12 +325,12 @@ func TestVxvy_7(t *testing.T) {
 	opcode := newOpcode(0x8AB7)
 	tcase := newTestCase(t, "VXVY 0x07")
 
-	vm.registers.V[opcode.x] = 0xFF
-	vm.registers.V[opcode.y] = 0x0A
+	vm.registers.V[opcode.x()] = 0xFF
+	vm.registers.V[opcode.y()] = 0x0A
 
 	vm.vxvy(opcode)
 	tcase.assertEqualVx(0x0F, 0x00)
-	tcase.assertEqualVx(opcode.x, ((0x0A - 0xFF) & 0xFF))
+	tcase.assertEqualVx(opcode.x(), ((0x0A - 0xFF) & 0xFF))
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -333,11 +340,11 @@ func TestVxvy_e(t *testing.T) {
 	opcode := newOpcode(0x8ABE)
 	tcase := newTestCase(t, "VXVY 0x0E")
 
-	vm.registers.V[opcode.x] = 0x10
+	vm.registers.V[opcode.x()] = 0x10
 
 	vm.vxvy(opcode)
 	tcase.assertEqualVx(0x0F, (0x10 & 0x80))
-	tcase.assertEqualVx(opcode.x, (0x10 << 1))
+	tcase.assertEqualVx(opcode.x(), (0x10 << 1))
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -347,8 +354,8 @@ func TestSnevxvy_skip(t *testing.T) {
 	opcode := newOpcode(0x9AB0)
 	tcase := newTestCase(t, "SNEVXVY skip")
 
-	vm.registers.V[opcode.x] = 0x00
-	vm.registers.V[opcode.y] = 0x10
+	vm.registers.V[opcode.x()] = 0x00
+	vm.registers.V[opcode.y()] = 0x10
 
 	vm.snevxvy(opcode)
 	tcase.assertEqualPC(0x204)
@@ -360,8 +367,8 @@ func TestSnevxvy(t *testing.T) {
 	opcode := newOpcode(0x9AB0)
 	tcase := newTestCase(t, "SNEVXVY")
 
-	vm.registers.V[opcode.x] = 0x10
-	vm.registers.V[opcode.y] = 0x10
+	vm.registers.V[opcode.x()] = 0x10
+	vm.registers.V[opcode.y()] = 0x10
 
 	vm.snevxvy(opcode)
 	tcase.assertEqualPC(0x202)
@@ -374,7 +381,7 @@ func TestLdi(t *testing.T) {
 	tcase := newTestCase(t, "LDI")
 
 	vm.ldi(opcode)
-	tcase.assertEqualI(opcode.nnn)
+	tcase.assertEqualI(opcode.nnn())
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -387,7 +394,7 @@ func TestJpv0(t *testing.T) {
 	vm.registers.V[0x00] = 0x10
 
 	vm.jpv0(opcode)
-	tcase.assertEqualPC(0x10 + opcode.nnn)
+	tcase.assertEqualPC(0x10 + opcode.nnn())
 
 	vm.Reset()
 }
@@ -396,13 +403,82 @@ func TestRnd(t *testing.T) {
 	opcode := newOpcode(0xCABC)
 	tcase := newTestCase(t, "RND")
 
-	vm.registers.V[opcode.x] = 0xFF // because we generate number in the half-open interval [0x00, 0xFF), so it can't be 0xFF
+	vm.SetRNG(&fixedRNG{0xFF})
 
 	vm.rnd(opcode)
-	tcase.assertNotEqualVx(opcode.x, 0xFF)
+	tcase.assertEqualVx(opcode.x(), 0xFF&opcode.nn())
 	tcase.assertEqualPC(0x202)
 
+	vm.SetRNG(NewGoRNG())
+	vm.Reset()
+}
+
+func TestRnd_go(t *testing.T) {
+	opcode := newOpcode(0xCAFF)
+	tcase := newTestCase(t, "RND go")
+
+	vm.SetSeed(0x01)
+
+	for _, value := range []byte{0x21, 0x0F, 0xC7} {
+		vm.rnd(opcode)
+		tcase.assertEqualVx(opcode.x(), value)
+	}
+
+	vm.Reset()
+}
+
+func TestRnd_vip(t *testing.T) {
+	opcode := newOpcode(0xCAFF)
+	tcase := newTestCase(t, "RND vip")
+
+	vm.SetRNG(NewVIPRNG())
+	vm.SetSeed(0x00)
+
+	// NOTE: The bytes below 0x200 of the machine are not used, the routine reads the interpreter page
+	for _, value := range []byte{0x00, 0x67, 0x8F, 0xBA, 0x98, 0x22} {
+		vm.rnd(opcode)
+		tcase.assertEqualVx(opcode.x(), value)
+	}
+
+	seen := make(map[byte]bool)
+	for i := 0; i < 2000; i++ {
+		vm.rnd(opcode)
+		seen[vm.registers.V[opcode.x()]] = true
+	}
+
+	if len(seen) < 0x80 {
+		tcase.test.Errorf("[%s] got %d distinct values in 2000 calls, want at least %d\n", tcase.name, len(seen), 0x80)
+	}
+
+	vm.SetRNG(NewGoRNG())
+	vm.Reset()
+}
+
+func TestDrw_planes(t *testing.T) {
+	tcase := newTestCase(t, "DRW planes")
+
+	vm.registers.I = 0x300
+	vm.memory.WriteArray(0x300, []byte{0x80, 0xC0}) // plane 1, plane 2
+
+	vm.ldf(newOpcode(0xF301))
+	vm.drw(newOpcode(0xD011))
+
+	if frame := vm.screen.Frame(); frame[0][0] != 0x3 || frame[2][0] != 0x2 {
+		tcase.test.Errorf("[%s] got pixels: %d %d, want pixels: %d %d\n", tcase.name, frame[0][0], frame[2][0], 0x3, 0x2)
+	}
+
+	vm.ldf(newOpcode(0xF101))
+	vm.clc(newOpcode(0x00E0))
+
+	if frame := vm.screen.Frame(); frame[0][0] != 0x2 || frame[2][0] != 0x2 {
+		tcase.test.Errorf("[%s] got pixels after 00E0 on plane 1: %d %d, want pixels: %d %d\n", tcase.name, frame[0][0], frame[2][0], 0x2, 0x2)
+	}
+
 	vm.Reset()
+
+	if planes := vm.screen.Planes(); planes != screen.PLANE_DEFAULT {
+		tcase.test.Errorf("[%s] got planes after a reset: %d, want planes: %d\n", tcase.name, planes, screen.PLANE_DEFAULT)
+	}
 }
 
 func TestDrw_carry(t *testing.T) {
@@ -450,6 +526,157 @@ func TestDrw(t *testing.T) {
 	vm.Reset()
 }
 
+func TestDrw_edges(t *testing.T) {
+	type pixel struct{ x, y byte }
+
+	cases := []struct {
+		name  string
+		hires bool
+		wrap  bool
+		x, y  byte
+		want  []pixel
+	}{
+		{"lores top left", false, false, 0, 0, []pixel{{0, 0}, {1, 0}, {0, 1}, {1, 1}}},
+		{"lores top right clip", false, false, 63, 0, []pixel{{63, 0}, {63, 1}}},
+		{"lores top right wrap", false, true, 63, 0, []pixel{{63, 0}, {63, 1}, {0, 0}, {0, 1}}},
+		{"lores bottom left clip", false, false, 0, 31, []pixel{{0, 31}, {1, 31}}},
+		{"lores bottom left wrap", false, true, 0, 31, []pixel{{0, 31}, {1, 31}, {0, 0}, {1, 0}}},
+		{"lores bottom right clip", false, false, 63, 31, []pixel{{63, 31}}},
+		{"lores bottom right wrap", false, true, 63, 31, []pixel{{63, 31}, {0, 31}, {63, 0}, {0, 0}}},
+		{"lores right edge clip", false, false, 63, 10, []pixel{{63, 10}, {63, 11}}},
+		{"lores bottom edge wrap", false, true, 20, 31, []pixel{{20, 31}, {21, 31}, {20, 0}, {21, 0}}},
+		{"lores start wrap", false, false, 64 + 62, 32 + 30, []pixel{{62, 30}, {63, 30}, {62, 31}, {63, 31}}},
+		{"lores start wrap clip", false, false, 255, 255, []pixel{{63, 31}}},
+		{"hires top left", true, false, 0, 0, []pixel{{0, 0}, {1, 0}, {0, 1}, {1, 1}}},
+		{"hires top right clip", true, false, 127, 0, []pixel{{127, 0}, {127, 1}}},
+		{"hires top right wrap", true, true, 127, 0, []pixel{{127, 0}, {127, 1}, {0, 0}, {0, 1}}},
+		{"hires bottom left clip", true, false, 0, 63, []pixel{{0, 63}, {1, 63}}},
+		{"hires bottom left wrap", true, true, 0, 63, []pixel{{0, 63}, {1, 63}, {0, 0}, {1, 0}}},
+		{"hires bottom right clip", true, false, 127, 63, []pixel{{127, 63}}},
+		{"hires bottom right wrap", true, true, 127, 63, []pixel{{127, 63}, {0, 63}, {127, 0}, {0, 0}}},
+		{"hires start wrap", true, false, 128 + 126, 64 + 62, []pixel{{126, 62}, {127, 62}, {126, 63}, {127, 63}}},
+	}
+
+	for _, c := range cases {
+		tcase := newTestCase(t, "DRW "+c.name)
+
+		vm.screen.SetHires(c.hires)
+		vm.SetWrap(c.wrap)
+		vm.memory.WriteArray(0x300, []byte{0xC0, 0xC0})
+		vm.registers.I = 0x300
+		vm.registers.V[0x00] = c.x
+		vm.registers.V[0x01] = c.y
+
+		vm.drw(newOpcode(0xD012))
+
+		width, height := vm.screenSize()
+		want := make(map[pixel]bool)
+		for _, p := range c.want {
+			want[p] = true
+		}
+
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				p := pixel{byte(x), byte(y)}
+				if got := vm.screen.GetPixel(p.x, p.y) == 1; got != want[p] {
+					tcase.test.Errorf("[%s] got pixel(%d, %d): %t, want pixel(%d, %d): %t\n", tcase.name, x, y, got, x, y, want[p])
+				}
+			}
+		}
+
+		vm.SetWrap(false)
+		vm.Reset()
+	}
+}
+
+func TestDrw_16(t *testing.T) {
+	tcase := newTestCase(t, "DRW 16x16")
+
+	sprite := make([]byte, 32)
+	for i := range sprite {
+		sprite[i] = 0xFF
+	}
+
+	vm.screen.SetHires(true)
+	vm.memory.WriteArray(0x300, sprite)
+	vm.registers.I = 0x300
+
+	vm.drw(newOpcode(0xD000))
+
+	for _, p := range [][2]byte{{0, 0}, {15, 0}, {0, 15}, {15, 15}} {
+		if vm.screen.GetPixel(p[0], p[1]) != 1 {
+			tcase.test.Errorf("[%s] got pixel(%d, %d): 0, want pixel(%d, %d): 1\n", tcase.name, p[0], p[1], p[0], p[1])
+		}
+	}
+
+	if vm.screen.GetPixel(16, 0) != 0 || vm.screen.GetPixel(0, 16) != 0 {
+		tcase.test.Errorf("[%s] got pixels outside of the sprite, want none\n", tcase.name)
+	}
+
+	vm.Reset()
+}
+
+func TestDrw_rows(t *testing.T) {
+	cases := []struct {
+		name  string
+		hires bool
+		y     byte
+		want  byte
+	}{
+		{"lores", false, 0, 1},
+		{"hires", true, 0, 2},
+		{"hires clipped", true, 62, 3},
+	}
+
+	for _, c := range cases {
+		tcase := newTestCase(t, "DRW rows "+c.name)
+
+		// 4 rows, the first and the third hit the pixels drawn before unless they are clipped at the bottom
+		vm.screen.SetHires(c.hires)
+		vm.memory.WriteArray(0x300, []byte{0x80, 0x00, 0x80, 0x00})
+		vm.registers.I = 0x300
+		vm.registers.V[0x01] = c.y
+		vm.drw(newOpcode(0xD011))
+
+		vm.registers.V[0x01] = c.y + 2
+		vm.drw(newOpcode(0xD011))
+
+		vm.screen.(*screen.MockWindow).TakeCollisions()
+		vm.registers.V[0x01] = c.y
+		vm.drw(newOpcode(0xD014))
+		tcase.assertEqualVx(0x0F, c.want)
+
+		collisions := vm.screen.(*screen.MockWindow).TakeCollisions()
+		if c.hires && collisions[0][c.y] != 1 {
+			tcase.test.Errorf("[%s] got collision(0, %d): %d, want collision(0, %d): %d\n", tcase.name, c.y, collisions[0][c.y], c.y, 1)
+		}
+
+		if !c.hires && (collisions[0][0] != 1 || collisions[1][1] != 1 || collisions[2][0] != 0) {
+			tcase.test.Errorf("[%s] got lores collision not covering 2x2 hires pixels\n", tcase.name)
+		}
+
+		vm.Reset()
+	}
+}
+
+func TestClc_hires(t *testing.T) {
+	tcase := newTestCase(t, "CLC hires")
+
+	vm.clc(newOpcode(0x00FF))
+	if !vm.screen.Hires() {
+		tcase.test.Errorf("[%s] got lores, want hires\n", tcase.name)
+	}
+
+	vm.clc(newOpcode(0x00FE))
+	if vm.screen.Hires() {
+		tcase.test.Errorf("[%s] got hires, want lores\n", tcase.name)
+	}
+
+	tcase.assertEqualPC(0x204)
+
+	vm.Reset()
+}
+
 func TestSkp_9e_skip(t *testing.T) {
 	opcode := newOpcode(0xE29E)
 	tcase := newTestCase(t, "SKP 0x09 skip")
@@ -500,20 +727,31 @@ func TestLdf_07(t *testing.T) {
 	vm.delayTimer = 0x20
 
 	vm.ldf(opcode)
-	tcase.assertEqualVx(opcode.x, 0x20)
+	tcase.assertEqualVx(opcode.x(), 0x20)
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
 }
 
+func TestLdf_0A_wait(t *testing.T) {
+	opcode := newOpcode(0xF30A)
+	tcase := newTestCase(t, "LDF 0x0A wait")
+
+	vm.ldf(opcode)
+	tcase.assertEqualPC(0x200)
+
+	vm.Reset()
+}
+
 func TestLdf_0A(t *testing.T) {
 	opcode := newOpcode(0xF30A)
 	tcase := newTestCase(t, "LDF 0x0A")
 
-	go vm.ldf(opcode)
-	vm.keyPressed <- sdl.K_3
+	vm.ldf(opcode)
+	vm.applyInput(movie.Input{Key: 0x03, Pressed: true})
+	vm.ldf(opcode)
 
-	tcase.assertEqualVx(opcode.x, sdl.K_3)
+	tcase.assertEqualVx(opcode.x(), 0x03)
 	tcase.assertEqualPC(0x202)
 
 	vm.Reset()
@@ -523,7 +761,7 @@ func TestLdf_15(t *testing.T) {
 	opcode := newOpcode(0xFA15)
 	tcase := newTestCase(t, "LDF 0x15")
 
-	vm.registers.V[opcode.x] = 0x10
+	vm.registers.V[opcode.x()] = 0x10
 
 	vm.ldf(opcode)
 	tcase.assertEqualDelayTimer(0x10)
@@ -536,7 +774,7 @@ func TestLdf_18(t *testing.T) {
 	opcode := newOpcode(0xFA18)
 	tcase := newTestCase(t, "LDF 0x18")
 
-	vm.registers.V[opcode.x] = 0x10
+	vm.registers.V[opcode.x()] = 0x10
 
 	vm.ldf(opcode)
 	tcase.assertEqualSoundTimer(0x10)
@@ -550,7 +788,7 @@ func TestLdf_1E(t *testing.T) {
 	tcase := newTestCase(t, "LDF 0x1E")
 
 	vm.registers.I = 0x10
-	vm.registers.V[opcode.x] = 0x20
+	vm.registers.V[opcode.x()] = 0x20
 
 	vm.ldf(opcode)
 	tcase.assertEqualI(0x30)
@@ -563,7 +801,7 @@ func TestLdf_29(t *testing.T) {
 	opcode := newOpcode(0xFC29)
 	tcase := newTestCase(t, "LDF 0x29")
 
-	vm.registers.V[opcode.x] = 0x05
+	vm.registers.V[opcode.x()] = 0x05
 
 	vm.ldf(opcode)
 	tcase.assertEqualI(0x19)
@@ -572,11 +810,25 @@ func TestLdf_29(t *testing.T) {
 	vm.Reset()
 }
 
+func TestLdf_30(t *testing.T) {
+	opcode := newOpcode(0xFC30)
+	tcase := newTestCase(t, "LDF 0x30")
+
+	vm.registers.V[opcode.x()] = 0x05
+
+	vm.ldf(opcode)
+	tcase.assertEqualI(LARGE_FONT_ADDRESS + 0x32)
+	tcase.assertEqualMemory(LARGE_FONT_ADDRESS+0x32, largeFonts[FONT_SCHIP][0x32])
+	tcase.assertEqualPC(0x202)
+
+	vm.Reset()
+}
+
 func TestLdf_33(t *testing.T) {
 	opcode := newOpcode(0xFC33)
 	tcase := newTestCase(t, "LDF 0x33")
 
-	vm.registers.V[opcode.x] = 0xFC
+	vm.registers.V[opcode.x()] = 0xFC
 
 	vm.ldf(opcode)
 	tcase.assertEqualMemory(vm.registers.I, (0xFC / 100))
@@ -591,15 +843,15 @@ func TestLdf_55(t *testing.T) {
 	opcode := newOpcode(0xFA55)
 	tcase := newTestCase(t, "LDF 0x55")
 
-	for i := byte(0); i <= opcode.x; i++ {
+	for i := byte(0); i <= opcode.x(); i++ {
 		vm.registers.V[i] = i
 	}
 
 	vm.ldf(opcode)
-	tcase.assertEqualI(uint16(opcode.x) + 1)
+	tcase.assertEqualI(uint16(opcode.x()) + 1)
 
-	vm.registers.I -= (uint16(opcode.x) + 1) // because I is incemented opcode.x times
-	for i := byte(0); i <= opcode.x; i++ {
+	vm.registers.I -= (uint16(opcode.x()) + 1) // because I is incemented opcode.x() times
+	for i := byte(0); i <= opcode.x(); i++ {
 		tcase.assertEqualMemory(vm.registers.I+uint16(i), i)
 	}
 
@@ -616,10 +868,10 @@ func TestLdf_65(t *testing.T) {
 
 	vm.ldf(opcode)
 
-	tcase.assertEqualI(uint16(opcode.x + 1))
-	vm.registers.I -= (uint16(opcode.x) + 1) // because I is incremented opcode.x times
+	tcase.assertEqualI(uint16(opcode.x() + 1))
+	vm.registers.I -= (uint16(opcode.x()) + 1) // because I is incremented opcode.x() times
 
-	for i := byte(0); i <= opcode.x; i++ {
+	for i := byte(0); i <= opcode.x(); i++ {
 		tcase.assertEqualVx(i, i)
 	}
 
@@ -627,3 +879,570 @@ func TestLdf_65(t *testing.T) {
 
 	vm.Reset()
 }
+
+func TestRunFrame(t *testing.T) {
+	tcase := newTestCase(t, "RunFrame")
+
+	vm.memory.WriteArray(0x200, []byte{0x12, 0x00}) // JP 0x200
+	vm.delayTimer = 0x05
+	vm.soundTimer = 0x01
+
+	vm.RunFrame()
+	tcase.assertEqualDelayTimer(0x04)
+	tcase.assertEqualSoundTimer(0x00)
+	tcase.assertEqualFrame(1)
+
+	vm.Reset()
+}
+
+func TestRunFrame_vip(t *testing.T) {
+	tcase := newTestCase(t, "RunFrame vip")
+
+	vm.SetTiming(VIPTiming{})
+	vm.memory.WriteArray(0x200, []byte{0x12, 0x00}) // JP 0x200
+
+	// 36 jumps of 52 cycles, the 36 cycles past the budget of 1836 go to the next frame
+	vm.RunFrame()
+	if vm.cycles != 1872 || vm.overrun != 36 {
+		tcase.test.Errorf("[%s] got cycles: %d, overrun: %d, want cycles: %d, overrun: %d\n", tcase.name, vm.cycles, vm.overrun, 1872, 36)
+	}
+
+	vm.RunFrame()
+	if vm.cycles != 1856 || vm.overrun != 20 {
+		tcase.test.Errorf("[%s] got cycles: %d, overrun: %d, want cycles: %d, overrun: %d\n", tcase.name, vm.cycles, vm.overrun, 1856, 20)
+	}
+
+	vm.SetTiming(FixedTiming{})
+	vm.Reset()
+}
+
+func TestRunFrame_vip_jump(t *testing.T) {
+	tcase := newTestCase(t, "RunFrame vip jump")
+
+	vm.SetTiming(VIPTiming{})
+	vm.memory.WriteArray(0x200, []byte{0x12, 0x04}) // JP 0x204
+
+	op := newOpcode(vm.memory.ReadOpcode(0x200))
+	vm.exec(op)
+
+	if cycles := vm.charge(0, VIP_FRAME_CYCLES, op, 0, 0x200); cycles != 52 {
+		tcase.test.Errorf("[%s] got cycles: %d, want cycles: %d\n", tcase.name, cycles, 52)
+	}
+
+	vm.SetTiming(FixedTiming{})
+	vm.Reset()
+}
+
+func TestRunFrame_vip_drw(t *testing.T) {
+	tcase := newTestCase(t, "RunFrame vip drw")
+
+	vm.SetTiming(VIPTiming{})
+	vm.memory.WriteArray(0x200, []byte{0xD0, 0x01, 0x12, 0x00}) // DRW V0, V0, 1; JP 0x200
+	vm.registers.I = 0x300
+
+	vm.RunFrame()
+	tcase.assertEqualPC(0x202)
+
+	if vm.cycles != VIP_FRAME_CYCLES-VIP_INTERRUPT_CYCLES {
+		tcase.test.Errorf("[%s] got cycles: %d, want cycles: %d\n", tcase.name, vm.cycles, VIP_FRAME_CYCLES-VIP_INTERRUPT_CYCLES)
+	}
+
+	vm.SetTiming(FixedTiming{})
+	vm.Reset()
+}
+
+func TestRunFrame_displayWait(t *testing.T) {
+	tcase := newTestCase(t, "RunFrame display wait")
+
+	vm.SetDisplayWait(true)
+	vm.memory.WriteArray(0x200, []byte{0xD0, 0x01, 0xD0, 0x01}) // DRW V0, V0, 1; DRW V0, V0, 1
+	vm.memory.Write(0x300, 0x80)
+	vm.registers.I = 0x300
+
+	vm.RunFrame()
+	tcase.assertEqualPC(0x202)
+
+	if front := vm.screen.(*screen.MockWindow).Front(); front[0][0] != 1 {
+		tcase.test.Errorf("[%s] got front[0][0]: %d, want front[0][0]: %d\n", tcase.name, front[0][0], 1)
+	}
+
+	vm.RunFrame()
+	tcase.assertEqualPC(0x204)
+
+	if front := vm.screen.(*screen.MockWindow).Front(); front[0][0] != 0 {
+		tcase.test.Errorf("[%s] got front[0][0]: %d, want front[0][0]: %d\n", tcase.name, front[0][0], 0)
+	}
+
+	vm.SetDisplayWait(false)
+	vm.Reset()
+}
+
+func TestRunFrame_profiler(t *testing.T) {
+	tcase := newTestCase(t, "RunFrame profiler")
+
+	profiler := profile.NewProfiler(ROM_ADDRESS)
+	vm.SetProfiler(profiler)
+	vm.memory.WriteArray(0x200, []byte{0x22, 0x04, 0x12, 0x00, 0x00, 0xEE}) // CALL 0x204; JP 0x200; RET
+
+	vm.RunFrame()
+
+	// 10 instructions, CALL, RET, JP repeated
+	if count := profiler.Profile().Counts[0x200]; count != 4 {
+		tcase.test.Errorf("[%s] got count: %d, want count: %d\n", tcase.name, count, 4)
+	}
+
+	if s := profiler.Subroutines()[0]; s.Address != 0x204 || s.Calls != 4 || s.Self != 3 {
+		tcase.test.Errorf("[%s] got subroutine: %+v, want 0x204 with 4 calls, self 3\n", tcase.name, s)
+	}
+
+	if frames := profiler.Frames(); len(frames) != 1 || frames[0] != 10 {
+		tcase.test.Errorf("[%s] got frames: %v, want frames: %v\n", tcase.name, frames, []uint64{10})
+	}
+
+	vm.SetProfiler(nil)
+	vm.Reset()
+}
+
+func TestRunFrame_coverage(t *testing.T) {
+	tcase := newTestCase(t, "RunFrame coverage")
+
+	c := coverage.New("test.ch8", "", ROM_ADDRESS)
+	vm.SetCoverage(c)
+	vm.memory.WriteArray(0x200, []byte{0x70, 0x01, 0x30, 0x03, 0x12, 0x00, 0x12, 0x06}) // ADD V0, 0x01; SE V0, 0x03; JP 0x200; JP 0x206
+
+	vm.RunFrame()
+
+	if c.Count(0x200) != 3 || c.Count(0x204) != 2 || c.Count(0x206) != 2 {
+		tcase.test.Errorf("[%s] got counts: %d, %d, %d, want counts: 3, 2, 2\n", tcase.name, c.Count(0x200), c.Count(0x204), c.Count(0x206))
+	}
+
+	if branch := c.Branch(0x202); branch.Taken != 1 || branch.NotTaken != 2 {
+		tcase.test.Errorf("[%s] got branch: %+v, want taken 1, not taken 2\n", tcase.name, branch)
+	}
+
+	vm.SetCoverage(nil)
+	vm.Reset()
+}
+
+func TestRunFrame_access(t *testing.T) {
+	tcase := newTestCase(t, "RunFrame access")
+
+	access := memory.NewAccessMap(memory.CHIP8_MEMORY_SIZE)
+	vm.SetAccessMap(access)
+	vm.memory.WriteArray(0x200, []byte{0xA2, 0x08, 0xD0, 0x01, 0x60, 0x12, 0xF0, 0x55, 0x00, 0x00}) // LD I, 0x208; DRW V0, V0, 1; LD V0, 0x12; LD [I], V0
+
+	vm.RunFrame()
+
+	if a := access.Access(0x202); a != memory.ACCESS_EXECUTE {
+		tcase.test.Errorf("[%s] got access[0x202]: %04b, want access[0x202]: %04b\n", tcase.name, a, memory.ACCESS_EXECUTE)
+	}
+
+	// 0x208 is read by DRW, written by FX55 and then executed as 1200, JP 0x200
+	if a := access.Access(0x208); a != memory.ACCESS_READ|memory.ACCESS_WRITE|memory.ACCESS_EXECUTE|memory.ACCESS_SMC {
+		tcase.test.Errorf("[%s] got access[0x208]: %04b, want access[0x208]: %04b\n", tcase.name, a, memory.ACCESS_READ|memory.ACCESS_WRITE|memory.ACCESS_EXECUTE|memory.ACCESS_SMC)
+	}
+
+	vm.SetAccessMap(nil)
+	vm.Reset()
+}
+
+func TestWatchBus(t *testing.T) {
+	tcase := newTestCase(t, "WatchBus")
+
+	bus.Watch(0x300, memory.WATCH_READ|memory.WATCH_WRITE)
+	bus.Watch(0x301, memory.WATCH_WRITE)
+	hits = nil
+
+	vm.memory.WriteArray(0x200, []byte{0xA3, 0x00, 0x60, 0x2A, 0xF0, 0x55, 0xF1, 0x65}) // LD I, 0x300; LD V0, 0x2A; LD [I], V0; LD V1, [I]
+	for i := 0; i < 4; i++ {
+		vm.Step()
+	}
+
+	// FX55 leaves I at 0x301, so FX65 reads 0x301 and 0x302, neither watched for reads
+	tcase.assertEqualHits([]memory.WatchHit{{Kind: memory.WATCH_WRITE, Address: 0x300, Data: 0x2A}})
+
+	bus.Unwatch(0x300)
+	bus.Unwatch(0x301)
+	hits = nil
+	vm.Reset()
+}
+
+func TestTiming_vip_drw(t *testing.T) {
+	timing := VIPTiming{}
+
+	aligned, _ := timing.Cost(newOpcode(0xD125), 0x08, false)
+	unaligned, _ := timing.Cost(newOpcode(0xD125), 0x0B, false)
+
+	if aligned != 40+68+5*46 || unaligned != 40+68+5*(46+3*20) {
+		t.Errorf("got cycles: %d, %d, want cycles: %d, %d\n", aligned, unaligned, 40+68+5*46, 40+68+5*(46+3*20))
+	}
+}
+
+func TestRunFrame_replay(t *testing.T) {
+	tcase := newTestCase(t, "RunFrame replay")
+
+	vm.memory.WriteArray(0x200, []byte{0x12, 0x00}) // JP 0x200
+	vm.ReplayMovie(&movie.Movie{
+		Seed: 0x01,
+		IPF:  10,
+		Inputs: []movie.Input{
+			{Frame: 1, Key: 0x05, Pressed: true},
+			{Frame: 2, Key: 0x05, Pressed: false},
+		},
+	})
+
+	vm.RunFrame()
+	tcase.assertEqualKeys(0x05, 0x00)
+
+	vm.RunFrame()
+	tcase.assertEqualKeys(0x05, 0x01)
+
+	vm.RunFrame()
+	tcase.assertEqualKeys(0x05, 0x00)
+	tcase.assertEqualFrame(3)
+
+	vm.Reset()
+}
+
+func TestReplayMovie_header(t *testing.T) {
+	tcase := newTestCase(t, "vm.ReplayMovie header")
+
+	if err := vm.ReplayMovie(&movie.Movie{IPF: 10, Timing: TIMING_VIP, DisplayWait: true, Wrap: true}); err != nil {
+		tcase.test.Fatalf("[%s] vm.ReplayMovie(): %v\n", tcase.name, err)
+	}
+
+	if vm.timing.Name() != TIMING_VIP || !vm.displayWait || !vm.wrap {
+		tcase.test.Errorf("[%s] got timing: %s, display wait: %t, wrap: %t, want timing: %s, display wait: %t, wrap: %t\n", tcase.name, vm.timing.Name(), vm.displayWait, vm.wrap, TIMING_VIP, true, true)
+	}
+
+	vip, _ := NewPlatform(PLATFORM_VIP)
+	if err := vm.ReplayMovie(&movie.Movie{IPF: 10, Platform: vip.movie()}); err == nil {
+		tcase.test.Errorf("[%s] got err: nil, want err for a movie recorded on %s\n", tcase.name, PLATFORM_VIP)
+	}
+
+	if err := vm.ReplayMovie(&movie.Movie{IPF: 10, ROM: rom.SHA1([]byte{0x12, 0x00})}); err == nil {
+		tcase.test.Errorf("[%s] got err: nil, want err for a movie recorded on another rom\n", tcase.name)
+	}
+
+	vm.replay = nil
+	vm.SetTiming(FixedTiming{})
+	vm.SetDisplayWait(false)
+	vm.SetWrap(false)
+	vm.Reset()
+}
+
+func TestSetSeed(t *testing.T) {
+	opcode := newOpcode(0xCAFF)
+	tcase := newTestCase(t, "SetSeed")
+
+	vm.SetSeed(0x1234)
+	vm.rnd(opcode)
+	value := vm.registers.V[opcode.x()]
+
+	vm.Reset()
+	vm.rnd(opcode)
+	tcase.assertEqualVx(opcode.x(), value)
+
+	vm.Reset()
+}
+
+func TestSoftReset(t *testing.T) {
+	tcase := newTestCase(t, "vm.SoftReset")
+
+	vm.registers.PC = 0x512
+	vm.registers.V[0x01] = 0x11
+	vm.memory.Write(0x300, 0xFF)
+
+	vm.SoftReset()
+
+	tcase.assertEqualPC(0x200)
+	tcase.assertEqualVx(0x01, 0x00)
+	tcase.assertEqualMemory(0x300, 0xFF)
+
+	vm.Reset()
+}
+
+func TestHardReset(t *testing.T) {
+	tcase := newTestCase(t, "vm.HardReset")
+
+	vm.LoadROM([]byte{0x12, 0x34})
+	vm.memory.Write(0x200, 0xFF)
+	vm.memory.Write(0x300, 0xFF)
+
+	vm.HardReset()
+
+	tcase.assertEqualMemory(0x200, 0x12)
+	tcase.assertEqualMemory(0x201, 0x34)
+	tcase.assertEqualMemory(0x300, 0x00)
+
+	vm.rom = nil
+	vm.Reset()
+}
+
+func TestCommand_speed(t *testing.T) {
+	tcase := newTestCase(t, "command speed")
+
+	for _, step := range []struct {
+		command screen.Command
+		ipf     uint64
+	}{
+		{screen.CMD_SPEED_DOWN, 5},
+		{screen.CMD_SPEED_DOWN, 1},
+		{screen.CMD_SPEED_DOWN, 1},
+		{screen.CMD_SPEED_UP, 5},
+		{screen.CMD_SPEED_UP, 10},
+	} {
+		vm.command(step.command)
+
+		if vm.ipf != step.ipf {
+			tcase.test.Errorf("[%s] got ipf: %d, want ipf: %d\n", tcase.name, vm.ipf, step.ipf)
+		}
+	}
+
+	vm.Reset()
+}
+
+func TestCommand_pause(t *testing.T) {
+	tcase := newTestCase(t, "command pause")
+
+	vm.command(screen.CMD_PAUSE)
+	if !vm.paused {
+		tcase.test.Errorf("[%s] got paused: false, want paused: true\n", tcase.name)
+	}
+
+	vm.command(screen.CMD_PAUSE)
+	if vm.paused {
+		tcase.test.Errorf("[%s] got paused: true, want paused: false\n", tcase.name)
+	}
+
+	vm.command(screen.CMD_FRAME_ADVANCE)
+	if !vm.paused || !vm.advance {
+		tcase.test.Errorf("[%s] got paused: %t, advance: %t, want paused: true, advance: true\n", tcase.name, vm.paused, vm.advance)
+	}
+
+	vm.paused = false
+	vm.advance = false
+	vm.Reset()
+}
+
+func TestSaveLoadState(t *testing.T) {
+	tcase := newTestCase(t, "vm.SaveState")
+
+	vm.registers.PC = 0x246
+	vm.registers.V[0x03] = 0x33
+	vm.delayTimer = 0x10
+	vm.memory.Write(0x300, 0xAB)
+	vm.stack.Push(memory.Frame{Caller: 0x222, Callee: 0x246})
+	vm.screen.SetPixel(0x05, 0x06)
+
+	state := vm.SaveState()
+	vm.Reset()
+	vm.LoadState(state)
+
+	tcase.assertEqualPC(0x246)
+	tcase.assertEqualVx(0x03, 0x33)
+	tcase.assertEqualDelayTimer(0x10)
+	tcase.assertEqualMemory(0x300, 0xAB)
+
+	if vm.screen.GetPixel(0x05, 0x06) != 1 {
+		tcase.test.Errorf("[%s] got screen[5][6] = 0, want screen[5][6] = 1\n", tcase.name)
+	}
+
+	tcase.assertEqualStackHead(0x222)
+
+	vm.Reset()
+}
+
+func TestReload(t *testing.T) {
+	tcase := newTestCase(t, "vm.Reload")
+
+	vm.LoadROM([]byte{0x12, 0x00})
+	vm.registers.V[0x03] = 0x33
+	vm.memory.Write(0x300, 0xAB)
+	vm.savedState = vm.SaveState()
+	vm.registers.V[0x03] = 0x00
+
+	vm.Reload([]byte{0x13, 0x00}, true)
+
+	tcase.assertEqualMemory(0x200, 0x13)
+	tcase.assertEqualMemory(0x300, 0xAB)
+	tcase.assertEqualVx(0x03, 0x33)
+
+	vm.Reload([]byte{0x14, 0x00}, false)
+
+	tcase.assertEqualMemory(0x200, 0x14)
+	tcase.assertEqualMemory(0x300, 0x00)
+	tcase.assertEqualVx(0x03, 0x00)
+
+	vm.LoadROM([]byte{0x12, 0x00, 0x60, 0x01})
+	vm.savedState = vm.SaveState()
+	vm.Reload([]byte{0x13, 0x00}, true)
+
+	tcase.assertEqualMemory(0x200, 0x13)
+	tcase.assertEqualMemory(0x202, 0x00)
+	tcase.assertEqualMemory(0x203, 0x00)
+
+	vm.savedState = nil
+	vm.rom = nil
+	vm.Reset()
+}
+
+func TestReload_movie(t *testing.T) {
+	tcase := newTestCase(t, "vm.Reload movie")
+
+	vm.LoadROM([]byte{0x12, 0x00})
+	vm.replay = &movie.Movie{}
+	vm.RequestReload([]byte{0x13, 0x00})
+	vm.handleCommands()
+
+	tcase.assertEqualMemory(0x200, 0x12)
+
+	vm.replay = nil
+	vm.rom = nil
+	vm.Reset()
+}
+
+func TestReload_size(t *testing.T) {
+	tcase := newTestCase(t, "vm.Reload size")
+
+	vm.LoadROM([]byte{0x12, 0x00})
+
+	if err := vm.Reload(make([]byte, memory.CHIP8_MEMORY_SIZE), false); err == nil {
+		tcase.test.Errorf("[%s] got err: nil, want err for a rom larger than the memory\n", tcase.name)
+	}
+
+	tcase.assertEqualMemory(0x200, 0x12)
+
+	vm.rom = nil
+	vm.Reset()
+}
+
+func TestSetPlatform(t *testing.T) {
+	tcase := newTestCase(t, "vm.SetPlatform")
+
+	platform, _ := NewPlatform(PLATFORM_ETI660)
+	platform.FontAddress = 0x0C0
+
+	if err := vm.SetPlatform(platform); err != nil {
+		tcase.test.Fatalf("[%s] vm.SetPlatform(): %v\n", tcase.name, err)
+	}
+
+	tcase.assertEqualPC(0x600)
+	tcase.assertEqualMemory(0x0C0, platform.Font[0])
+
+	if err := vm.LoadROM([]byte{0xF1, 0x29}); err != nil { // LD F, V1
+		tcase.test.Errorf("[%s] vm.LoadROM(): %v\n", tcase.name, err)
+	}
+
+	vm.registers.V[0x01] = 0x02
+	vm.Step()
+	tcase.assertEqualI(0x0C0 + 2*5)
+
+	vm.SoftReset()
+	tcase.assertEqualPC(0x600)
+
+	platform.MemorySize = memory.MAX_MEMORY_SIZE
+	if err := vm.SetPlatform(platform); err == nil {
+		tcase.test.Errorf("[%s] got err: nil, want err for a memory size that differs from the memory\n", tcase.name)
+	}
+
+	chip8, _ := NewPlatform(PLATFORM_CHIP8)
+	vm.SetPlatform(chip8)
+	vm.rom = nil
+	vm.Reset()
+}
+
+func TestBacktrace(t *testing.T) {
+	tcase := newTestCase(t, "vm.Backtrace")
+
+	vm.registers.V[0x01] = 0x11
+	vm.call(newOpcode(0x2300))
+	vm.executed = 5
+	vm.registers.PC = 0x304
+	vm.call(newOpcode(0x2400))
+	vm.registers.PC = 0x402
+
+	calls := vm.Backtrace()
+	want := []string{
+		"0x402 in sub_0x400, called from 0x304 at cycle 5",
+		"0x304 in sub_0x300, called from 0x200 at cycle 0",
+		"0x200 in main",
+	}
+
+	if len(calls) != len(want) {
+		tcase.test.Fatalf("[%s] got %d calls, want %d calls\n", tcase.name, len(calls), len(want))
+	}
+
+	for i, call := range calls {
+		if call.String() != want[i] {
+			tcase.test.Errorf("[%s] got #%d: %q, want #%d: %q\n", tcase.name, i, call, i, want[i])
+		}
+	}
+
+	if calls[1].Frame.V[0x01] != 0x11 {
+		tcase.test.Errorf("[%s] got V[0x01] at entry: 0x%02x, want V[0x01] at entry: 0x%02x\n", tcase.name, calls[1].Frame.V[0x01], 0x11)
+	}
+
+	vm.clc(newOpcode(0x00EE))
+	tcase.assertEqualPC(0x306)
+
+	vm.Reset()
+}
+
+func TestCall_overflow(t *testing.T) {
+	tcase := newTestCase(t, "CALL overflow")
+
+	var buffer bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buffer)
+
+	for i := 0; i <= memory.CHIP8_STACK_SIZE; i++ {
+		vm.call(newOpcode(0x2300))
+	}
+
+	tcase.assertEqualPC(0x300)
+
+	if vm.stack.Depth() != memory.CHIP8_STACK_SIZE {
+		tcase.test.Errorf("[%s] got depth: %d, want depth: %d\n", tcase.name, vm.stack.Depth(), memory.CHIP8_STACK_SIZE)
+	}
+
+	if !strings.Contains(buffer.String(), "stack overflow, 16 frames deep, PC: 0x300") || !strings.Contains(buffer.String(), "#16 0x200 in main") {
+		tcase.test.Errorf("[%s] got report: %q, want a stack overflow with a backtrace\n", tcase.name, buffer.String())
+	}
+
+	// NOTE: Only the first fault until the next reset is reported
+	buffer.Reset()
+	vm.call(newOpcode(0x2300))
+
+	if buffer.Len() != 0 {
+		tcase.test.Errorf("[%s] got report: %q, want no report\n", tcase.name, buffer.String())
+	}
+
+	if !vm.paused {
+		tcase.test.Errorf("[%s] got paused: %t, want paused: %t\n", tcase.name, vm.paused, true)
+	}
+
+	vm.Reset()
+	vm.clc(newOpcode(0x00EE))
+	tcase.assertEqualPC(0x200)
+
+	if !strings.Contains(buffer.String(), "stack underflow, PC: 0x200") {
+		tcase.test.Errorf("[%s] got report: %q, want a stack underflow\n", tcase.name, buffer.String())
+	}
+
+	vm.paused = false
+	vm.Reset()
+}
+
+func TestStop(t *testing.T) {
+	machine := NewVirtualMachine(memory.NewMemory(memory.CHIP8_MEMORY_SIZE), memory.NewStack(memory.CHIP8_STACK_SIZE), &screen.MockWindow{}, 10, false)
+
+	go machine.EvalLoop()
+	machine.Stop()
+
+	select {
+	case <-machine.done:
+	default:
+		t.Errorf("got EvalLoop running after Stop, want EvalLoop stopped\n")
+	}
+}
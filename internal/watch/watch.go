@@ -0,0 +1,55 @@
+package watch
+
+import (
+	"os"
+	"time"
+)
+
+const POLL_INTERVAL = 500 * time.Millisecond
+
+type Watcher struct {
+	fname   string
+	modTime time.Time
+	size    int64
+}
+
+func NewWatcher(fname string) (*Watcher, error) {
+	info, err := os.Stat(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Watcher{
+		fname:   fname,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}, nil
+}
+
+// NOTE: A missing or empty file is usually a build in progress, so it is skipped until the next poll
+func (watcher *Watcher) Poll() ([]byte, bool) {
+	info, err := os.Stat(watcher.fname)
+	if err != nil || (info.ModTime().Equal(watcher.modTime) && info.Size() == watcher.size) {
+		return nil, false
+	}
+
+	buffer, err := os.ReadFile(watcher.fname)
+	if err != nil || len(buffer) == 0 {
+		return nil, false
+	}
+
+	watcher.modTime = info.ModTime()
+	watcher.size = info.Size()
+
+	return buffer, true
+}
+
+func (watcher *Watcher) Run(interval time.Duration, changed func([]byte)) {
+	for {
+		time.Sleep(interval)
+
+		if buffer, ok := watcher.Poll(); ok {
+			changed(buffer)
+		}
+	}
+}
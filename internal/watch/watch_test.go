@@ -0,0 +1,46 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPoll(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "rom.ch8")
+	os.WriteFile(fname, []byte{0x12, 0x00}, 0644)
+
+	watcher, err := NewWatcher(fname)
+	if err != nil {
+		t.Fatalf("NewWatcher(): %v\n", err)
+	}
+
+	if _, ok := watcher.Poll(); ok {
+		t.Errorf("got changed: true, want changed: false\n")
+	}
+
+	os.WriteFile(fname, []byte{0x12, 0x02}, 0644)
+	os.Chtimes(fname, time.Now(), time.Now().Add(time.Second))
+
+	buffer, ok := watcher.Poll()
+	if !ok || len(buffer) != 2 || buffer[1] != 0x02 {
+		t.Errorf("got buffer: %v, changed: %t, want buffer: %v, changed: true\n", buffer, ok, []byte{0x12, 0x02})
+	}
+
+	if _, ok := watcher.Poll(); ok {
+		t.Errorf("got changed: true after the change was seen, want changed: false\n")
+	}
+}
+
+func TestPoll_empty(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "rom.ch8")
+	os.WriteFile(fname, []byte{0x12, 0x00}, 0644)
+
+	watcher, _ := NewWatcher(fname)
+	os.WriteFile(fname, []byte{}, 0644)
+
+	if _, ok := watcher.Poll(); ok {
+		t.Errorf("got changed: true for an empty file, want changed: false\n")
+	}
+}
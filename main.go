@@ -4,52 +4,627 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"miya/internal/coverage"
 	"miya/internal/memory"
+	"miya/internal/movie"
+	"miya/internal/profile"
+	"miya/internal/rom"
 	"miya/internal/screen"
 	"miya/internal/vm"
+	"miya/internal/watch"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 )
 
+type options struct {
+	fname            string
+	delay            uint64
+	backgroundColor  string
+	pixelColor       string
+	colorsSet        bool
+	palette          string
+	debugMode        bool
+	screenshotDir    string
+	screenshotScale  int
+	record           string
+	headless         bool
+	frames           int
+	ipf              uint64
+	recordInput      string
+	replay           string
+	seed             int64
+	seedSet          bool
+	rng              string
+	timing           string
+	displayWait      bool
+	wrap             bool
+	collisions       bool
+	watch            bool
+	watchRestore     bool
+	romDir           string
+	romDB            string
+	scale            int
+	scaling          string
+	filter           screen.Filter
+	profile          string
+	pprof            string
+	coverage         string
+	warnSMC          bool
+	jit              bool
+	platform         string
+	memorySize       string
+	loadAddress      string
+	fontAddress      string
+	largeFontAddress string
+	stackDepth       int
+	font             string
+	largeFont        string
+}
+
 func main() {
-	var fname string
-	var delay uint64
-	var backgroundColor uint64
-	var pixelColor uint64
-	var debugMode bool
-
-	flag.StringVar(&fname, "fname", "", "Rom filename")
-	flag.Uint64Var(&delay, "delay", 1, "Delay in ms for virtualmachine and screen")
-	flag.Uint64Var(&backgroundColor, "background-color", 0x00000000, "Background color in uint32 for the screen")
-	flag.Uint64Var(&pixelColor, "pixel-color", 0xFFFFFF00, "Pixel color in uint32 for the screen")
-	flag.BoolVar(&debugMode, "debug-mode", false, "Run in debug mode")
+	var opts options
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "info":
+			runInfo(os.Args[2:])
+			return
+		case "cfg":
+			runCFG(os.Args[2:])
+			return
+		case "coverage":
+			runCoverage(os.Args[2:])
+			return
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		}
+	}
+
+	flag.StringVar(&opts.fname, "fname", "", "Rom filename")
+	flag.Uint64Var(&opts.delay, "delay", 1, "Delay in ms for the screen")
+	flag.Uint64Var(&opts.ipf, "ipf", 10, "Instructions per frame, the virtualmachine runs 60 frames per second")
+	flag.StringVar(&opts.backgroundColor, "background-color", "0x00000000", "Background color for the screen, uint32 or #RRGGBB")
+	flag.StringVar(&opts.pixelColor, "pixel-color", "0xFFFFFF00", "Pixel color for the screen, uint32 or #RRGGBB")
+	flag.StringVar(&opts.palette, "palette", "", "Palette preset (default, green, amber, lcd, octo) or a comma separated list of 2, 4 or 16 colors, overrides the colors")
+	flag.BoolVar(&opts.debugMode, "debug-mode", false, "Run in debug mode")
+	flag.StringVar(&opts.screenshotDir, "screenshot-dir", screen.SCREENSHOT_DIR, "Directory for screenshots taken with F12")
+	flag.IntVar(&opts.screenshotScale, "screenshot-scale", screen.SCREENSHOT_SCALE, "Integer scale of the screenshots")
+	flag.StringVar(&opts.record, "record", "", "Record gameplay to a .gif or to numbered .png/.pbm frames")
+	flag.BoolVar(&opts.headless, "headless", false, "Run without a window, saving a screenshot at the end")
+	flag.IntVar(&opts.frames, "frames", 600, "Number of 60Hz frames to run in headless mode")
+	flag.StringVar(&opts.recordInput, "record-input", "", "Record keypad input and the random seed to a movie file")
+	flag.StringVar(&opts.replay, "replay", "", "Replay keypad input from a movie file")
+	flag.Int64Var(&opts.seed, "seed", 0, "Seed for the CXNN random number generator, random if not set")
+	flag.StringVar(&opts.rng, "rng", vm.RNG_GO, "Random number generator for CXNN: go or vip")
+	flag.BoolVar(&opts.displayWait, "display-wait", false, "DXYN waits for the next frame like on the COSMAC VIP, at most one sprite is drawn per frame")
+	flag.BoolVar(&opts.collisions, "collisions", false, "Flash the pixels where sprites collided, always on in debug mode")
+	flag.BoolVar(&opts.wrap, "wrap", false, "Wrap sprites around the screen edges instead of clipping them")
+	flag.StringVar(&opts.timing, "timing", vm.TIMING_FIXED, "Timing model: fixed runs --ipf instructions per frame, vip charges the COSMAC VIP machine cycles")
+	flag.BoolVar(&opts.watch, "watch", false, "Reload the rom when the file changes")
+	flag.BoolVar(&opts.watchRestore, "watch-restore", false, "After a reload restore the state saved with F8")
+	flag.StringVar(&opts.romDir, "rom-dir", "roms", "Directory with roms for the browser, used when --fname is not set")
+	flag.StringVar(&opts.romDB, "rom-db", "", "programs.json of the CHIP-8 database, <rom-dir>/programs.json if not set")
+	flag.IntVar(&opts.scale, "scale", 10, "Initial window scale, the window is 64x32 pixels times scale")
+	flag.StringVar(&opts.scaling, "scaling", screen.SCALING_INTEGER, "Scaling of the screen in a resized window: integer or fit")
+	flag.IntVar(&opts.filter.Persistence, "persistence", 0, "Phosphor persistence, number of frames a pixel takes to fade out")
+	flag.BoolVar(&opts.filter.Blend, "blend", false, "Blend every frame with the previous one to reduce flicker")
+	flag.BoolVar(&opts.filter.Scanlines, "scanlines", false, "Darken every other line like a CRT")
+	flag.BoolVar(&opts.filter.Grid, "grid", false, "Draw a grid between the pixels")
+	flag.StringVar(&opts.profile, "profile", "", "Count the executions of every address into a profile for miya cfg and print a report at the end")
+	flag.StringVar(&opts.pprof, "pprof", "", "Write a gzipped pprof profile of the rom subroutines for go tool pprof, prints the report too")
+	flag.StringVar(&opts.coverage, "coverage", "", "Record every executed address and skip direction to a json file for miya coverage report")
+	flag.BoolVar(&opts.warnSMC, "warn-smc", false, "Log when the program counter enters bytes that were written at runtime")
+	flag.BoolVar(&opts.jit, "jit", false, "Run basic blocks as cached closures instead of interpreting every instruction, off in debug mode and while profiling")
+	flag.StringVar(&opts.platform, "platform", vm.PLATFORM_CHIP8, fmt.Sprintf("Memory layout of the machine: %s", strings.Join(vm.PlatformNames(), ", ")))
+	flag.StringVar(&opts.memorySize, "memory-size", "", "Memory size in bytes, overrides the platform, like 4096 or 0x1000")
+	flag.StringVar(&opts.loadAddress, "load-address", "", "Address the rom is loaded at, overrides the platform, like 0x600")
+	flag.StringVar(&opts.fontAddress, "font-address", "", "Address of the small font, overrides the platform, like 0x100")
+	flag.StringVar(&opts.largeFontAddress, "large-font-address", "", "Address of the large font, overrides the platform, like 0x150")
+	flag.IntVar(&opts.stackDepth, "stack-depth", 0, "Number of calls that fit on the stack, overrides the platform, -1 for unlimited")
+	flag.StringVar(&opts.font, "font", "", fmt.Sprintf("Small font for FX29, overrides the platform: %s or a binary file", strings.Join(vm.SmallFontNames(), ", ")))
+	flag.StringVar(&opts.largeFont, "large-font", "", fmt.Sprintf("Large font for FX30, overrides the platform: %s or a binary file", strings.Join(vm.LargeFontNames(), ", ")))
 	flag.Parse()
 
-	buffer, err := os.ReadFile(fname)
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "seed":
+			opts.seedSet = true
+		case "background-color", "pixel-color":
+			opts.colorsSet = true
+		}
+	})
+
+	if opts.fname == "" {
+		runBrowser(opts)
+		return
+	}
+
+	buffer, err := os.ReadFile(opts.fname)
 	if err != nil {
 		log.Fatalf("os.ReadFile(): %v\n", err)
 	}
 
-	mw, err := screen.NewMainWindow(fmt.Sprintf("CHIP8 - %s | %dms", fname, delay), 640, 320, backgroundColor, pixelColor)
-	if err != nil {
-		log.Fatalf("screen.NewMainWindow(): %v\n", err)
+	if opts.headless {
+		runHeadless(buffer, opts)
+		return
 	}
 
-	mem := memory.NewMemory(memory.CHIP8_MEMORY_SIZE)
-	stack := memory.NewStack(memory.CHIP8_STACK_SIZE)
-	vm := vm.NewVirtualMachine(mem, stack, mw, delay, debugMode)
+	mw := newMainWindow(fmt.Sprintf("CHIP8 - %s | %d ipf", opts.fname, opts.ipf), opts)
+	loadConfig(mw, buffer, opts)
+
+	if opts.record != "" {
+		if err := mw.StartRecording(opts.record); err != nil {
+			log.Fatalf("mw.StartRecording(): %v\n", err)
+		}
+	}
+
+	vm := newVirtualMachine(buffer, mw, opts)
+
+	if profiler := newProfiler(vm, opts); profiler != nil {
+		screen.OnExit(func() {
+			saveProfile(profiler, opts)
+		})
+	}
+
+	if c := newCoverage(vm, buffer, opts); c != nil {
+		screen.OnExit(func() {
+			saveCoverage(c, opts)
+		})
+	}
 
-	mem.WriteArray(0x200, buffer)
 	go vm.EvalLoop()
 
-	if debugMode {
-		dw, err := screen.NewDebugWindow("Debug", 380, 120)
+	if opts.watch {
+		watcher, err := watch.NewWatcher(opts.fname)
+		if err != nil {
+			log.Fatalf("watch.NewWatcher(): %v\n", err)
+		}
+
+		vm.SetWatchRestore(opts.watchRestore)
+		go watcher.Run(watch.POLL_INTERVAL, vm.RequestReload)
+	}
+
+	if opts.debugMode {
+		dw, err := screen.NewDebugWindow("Debug", 380, 320)
 		if err != nil {
 			log.Fatalf("screen.NewDebugWindow(): %v\n", err)
 		}
 
 		go vm.Debug()
-		screen.ShowWindows(delay, mw, dw)
+		screen.ShowWindows(opts.delay, mw, dw)
+	}
+
+	screen.ShowWindows(opts.delay, mw)
+}
+
+func runHeadless(buffer []byte, opts options) {
+	var recorder screen.Recorder
+	var err error
+
+	mock := &screen.MockWindow{}
+	config, err := rom.LoadConfig(configFilename(buffer))
+	if err != nil {
+		log.Printf("rom.LoadConfig(): %v\n", err)
+	}
+
+	_, palette := resolvePalette(opts, config.Palette)
+	display := screen.NewDisplay(opts.filter, palette)
+
+	if opts.record != "" {
+		recorder, err = screen.NewRecorder(opts.record, opts.screenshotScale)
+		if err != nil {
+			log.Fatalf("screen.NewRecorder(): %v\n", err)
+		}
+	}
+
+	opts.debugMode = false
+	vm := newVirtualMachine(buffer, mock, opts)
+	profiler := newProfiler(vm, opts)
+	c := newCoverage(vm, buffer, opts)
+
+	if err := screen.RunHeadless(opts.frames, vm.RunFrame, mock, display, recorder); err != nil {
+		log.Fatalf("screen.RunHeadless(): %v\n", err)
+	}
+
+	if profiler != nil {
+		saveProfile(profiler, opts)
+	}
+
+	if c != nil {
+		saveCoverage(c, opts)
+	}
+
+	fname, err := screen.Screenshot(opts.screenshotDir, display, opts.screenshotScale)
+	if err != nil {
+		log.Fatalf("screen.Screenshot(): %v\n", err)
+	}
+
+	log.Printf("Screenshot saved to %s\n", fname)
+}
+
+func runBrowser(opts options) {
+	var machine *vm.VirtualMachine
+	var db *rom.Database
+
+	mw := newMainWindow("CHIP8", opts)
+
+	if opts.romDB == "" {
+		opts.romDB = filepath.Join(opts.romDir, "programs.json")
+	}
+
+	if _, err := os.Stat(opts.romDB); err == nil {
+		if db, err = rom.LoadDatabase(opts.romDB); err != nil {
+			log.Printf("rom.LoadDatabase(): %v\n", err)
+		}
+	}
+
+	entries, err := rom.Scan(opts.romDir, db)
+	if err != nil {
+		log.Printf("rom.Scan(): %v\n", err)
+	}
+
+	recentFile := recentFilename()
+	recent := rom.LoadRecent(recentFile)
+
+	var recentEntries []rom.Entry
+	for _, path := range recent {
+		if entry, err := rom.NewEntry(path, db); err == nil {
+			recentEntries = append(recentEntries, entry)
+		}
 	}
 
-	screen.ShowWindows(delay, mw)
+	entries = append(recentEntries, entries...)
+	items := make([]screen.BrowserItem, len(entries))
+
+	for i, entry := range entries {
+		items[i] = screen.BrowserItem{
+			Title:       entry.Info.Title,
+			Detail:      strings.Join(entry.Info.Platforms, ", "),
+			Description: entry.Info.Description,
+		}
+
+		if i < len(recentEntries) {
+			items[i].Title = "* " + items[i].Title
+		}
+	}
+
+	// NOTE: The browser owns the window, so debug mode and movies are only available with --fname
+	opts.debugMode = false
+	opts.recordInput = ""
+	opts.replay = ""
+
+	launch := func(index int) error {
+		buffer, err := os.ReadFile(entries[index].Path)
+		if err != nil {
+			return err
+		}
+
+		if err := resolvePlatform(opts).Fits(buffer); err != nil {
+			return err
+		}
+
+		mw.Clear()
+		mw.Publish()
+		mw.SetTitle(fmt.Sprintf("CHIP8 - %s | %d ipf", entries[index].Info.Title, opts.ipf))
+		loadConfig(mw, buffer, opts)
+
+		machine = newVirtualMachine(buffer, mw, opts)
+		go machine.EvalLoop()
+
+		recent = rom.AddRecent(recent, entries[index].Path)
+		if err := rom.SaveRecent(recentFile, recent); err != nil {
+			log.Printf("rom.SaveRecent(): %v\n", err)
+		}
+
+		return nil
+	}
+
+	stop := func() {
+		machine.Stop()
+		mw.Clear()
+		mw.Publish()
+		mw.SetTitle("CHIP8")
+	}
+
+	browser, err := screen.NewBrowser(items, launch, stop)
+	if err != nil {
+		log.Fatalf("screen.NewBrowser(): %v\n", err)
+	}
+
+	mw.SetBrowser(browser)
+	screen.ShowWindows(opts.delay, mw)
+}
+
+func newMainWindow(title string, opts options) *screen.MainWindow {
+	if opts.scale < 1 {
+		log.Fatalf("invalid scale: %d\n", opts.scale)
+	}
+
+	name, palette := resolvePalette(opts, "")
+
+	mw, err := screen.NewMainWindow(title, int32(64*opts.scale), int32(32*opts.scale), palette)
+	if err != nil {
+		log.Fatalf("screen.NewMainWindow(): %v\n", err)
+	}
+
+	mw.SetPalette(name, palette)
+
+	if err := mw.SetScaling(opts.scaling); err != nil {
+		log.Fatalf("mw.SetScaling(): %v\n", err)
+	}
+
+	if opts.filter.Persistence < 0 {
+		log.Fatalf("invalid persistence: %d\n", opts.filter.Persistence)
+	}
+
+	if err := mw.SetFilter(opts.filter); err != nil {
+		log.Fatalf("mw.SetFilter(): %v\n", err)
+	}
+
+	mw.SetScreenshotOptions(opts.screenshotDir, opts.screenshotScale)
+	mw.SetCollisionOverlay(opts.collisions || opts.debugMode)
+
+	return mw
+}
+
+// NOTE: --palette wins over the palette saved for the rom, which wins over --background-color and --pixel-color
+func resolvePalette(opts options, saved string) (string, screen.Palette) {
+	if opts.palette != "" {
+		palette, err := screen.ParsePalette(opts.palette)
+		if err != nil {
+			log.Fatalf("screen.ParsePalette(): %v\n", err)
+		}
+
+		return opts.palette, palette
+	}
+
+	if saved != "" {
+		palette, err := screen.ParsePalette(saved)
+		if err == nil {
+			return saved, palette
+		}
+
+		log.Printf("screen.ParsePalette(): %v\n", err)
+	}
+
+	backgroundColor, err := screen.ParseColorString(opts.backgroundColor)
+	if err != nil {
+		log.Fatalf("screen.ParseColorString(): %v\n", err)
+	}
+
+	pixelColor, err := screen.ParseColorString(opts.pixelColor)
+	if err != nil {
+		log.Fatalf("screen.ParseColorString(): %v\n", err)
+	}
+
+	name := screen.PALETTE_DEFAULT
+	if opts.colorsSet {
+		name = ""
+	}
+
+	return name, screen.NewPalette(backgroundColor, pixelColor)
+}
+
+func loadConfig(mw *screen.MainWindow, buffer []byte, opts options) {
+	fname := configFilename(buffer)
+
+	config, err := rom.LoadConfig(fname)
+	if err != nil {
+		log.Printf("rom.LoadConfig(): %v\n", err)
+	}
+
+	mw.SetPalette(resolvePalette(opts, config.Palette))
+	mw.OnPaletteChange(func(name string) {
+		config.Palette = name
+
+		if err := rom.SaveConfig(fname, config); err != nil {
+			log.Printf("rom.SaveConfig(): %v\n", err)
+		}
+	})
+}
+
+func configFilename(buffer []byte) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+
+	return rom.ConfigFilename(filepath.Join(dir, "miya", "roms"), buffer)
+}
+
+func recentFilename() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+
+	return filepath.Join(dir, "miya", "recent")
+}
+
+func newVirtualMachine(buffer []byte, s screen.Chip8Screen, opts options) *vm.VirtualMachine {
+	platform := resolvePlatform(opts)
+	mem := memory.NewMemory(platform.MemorySize)
+	stack := memory.NewStack(platform.StackDepth)
+
+	rng, err := vm.NewRNG(opts.rng)
+	if err != nil {
+		log.Fatalf("vm.NewRNG(): %v\n", err)
+	}
+
+	machine := vm.NewVirtualMachine(mem, stack, s, opts.ipf, opts.debugMode)
+	machine.SetRNG(rng)
+
+	if err := machine.SetPlatform(platform); err != nil {
+		log.Fatalf("machine.SetPlatform(): %v\n", err)
+	}
+
+	timing, err := vm.NewTiming(opts.timing)
+	if err != nil {
+		log.Fatalf("vm.NewTiming(): %v\n", err)
+	}
+
+	machine.SetTiming(timing)
+	machine.SetDisplayWait(opts.displayWait)
+	machine.SetWrap(opts.wrap)
+	machine.SetJIT(opts.jit)
+
+	// NOTE: The access map feeds the heatmap of the debug window and the self-modifying code warnings
+	if opts.debugMode || opts.warnSMC {
+		machine.SetAccessMap(memory.NewAccessMap(platform.MemorySize))
+		machine.SetWarnSMC(opts.warnSMC)
+	}
+
+	if opts.seedSet {
+		machine.SetSeed(opts.seed)
+	}
+
+	if err := machine.LoadROM(buffer); err != nil {
+		log.Fatalf("machine.LoadROM(): %v\n", err)
+	}
+
+	if opts.replay != "" {
+		m, err := movie.Load(opts.replay)
+		if err != nil {
+			log.Fatalf("movie.Load(): %v\n", err)
+		}
+
+		if err := machine.ReplayMovie(m); err != nil {
+			log.Fatalf("machine.ReplayMovie(): %v\n", err)
+		}
+	}
+
+	if opts.recordInput != "" {
+		if err := machine.RecordMovie(opts.recordInput); err != nil {
+			log.Fatalf("machine.RecordMovie(): %v\n", err)
+		}
+	}
+
+	return machine
+}
+
+// NOTE: The flags override single values of the platform, so an ETI-660 with 8KB is --platform eti-660 --memory-size 0x2000
+func resolvePlatform(opts options) vm.Platform {
+	platform, err := vm.NewPlatform(opts.platform)
+	if err != nil {
+		log.Fatalf("vm.NewPlatform(): %v\n", err)
+	}
+
+	if opts.memorySize != "" {
+		platform.MemorySize = int(parseAddress("memory-size", opts.memorySize, memory.MAX_MEMORY_SIZE))
+	}
+
+	if opts.loadAddress != "" {
+		platform.LoadAddress = uint16(parseAddress("load-address", opts.loadAddress, memory.MAX_MEMORY_SIZE-1))
+	}
+
+	if opts.fontAddress != "" {
+		platform.FontAddress = uint16(parseAddress("font-address", opts.fontAddress, memory.MAX_MEMORY_SIZE-1))
+	}
+
+	if opts.largeFontAddress != "" {
+		platform.LargeFontAddress = uint16(parseAddress("large-font-address", opts.largeFontAddress, memory.MAX_MEMORY_SIZE-1))
+	}
+
+	if opts.stackDepth != 0 {
+		platform.StackDepth = opts.stackDepth
+	}
+
+	if opts.font != "" {
+		if platform.Font, err = vm.LoadSmallFont(opts.font); err != nil {
+			log.Fatalf("vm.LoadSmallFont(): %v\n", err)
+		}
+	}
+
+	if opts.largeFont != "" {
+		if platform.LargeFont, err = vm.LoadLargeFont(opts.largeFont); err != nil {
+			log.Fatalf("vm.LoadLargeFont(): %v\n", err)
+		}
+	}
+
+	if err := platform.Validate(); err != nil {
+		log.Fatalf("platform.Validate(): %v\n", err)
+	}
+
+	return platform
+}
+
+// NOTE: The tools read the rom at the load address of --platform or --load-address, the fallback when neither is set
+func loadAddressFlags(flags *flag.FlagSet) func(fallback uint16) uint16 {
+	platform := flags.String("platform", "", "Platform the rom is for, sets the load address")
+	address := flags.String("load-address", "", "Address the rom is loaded at, overrides the platform")
+
+	return func(fallback uint16) uint16 {
+		if *platform == "" && *address == "" {
+			return fallback
+		}
+
+		if *platform == "" {
+			*platform = vm.PLATFORM_CHIP8
+		}
+
+		return resolvePlatform(options{platform: *platform, loadAddress: *address}).LoadAddress
+	}
+}
+
+func parseAddress(name, s string, max uint64) uint64 {
+	value, err := strconv.ParseUint(s, 0, 32)
+	if err != nil || value > max {
+		log.Fatalf("invalid --%s: %q, want a number up to 0x%x\n", name, s, max)
+	}
+
+	return value
+}
+
+func newProfiler(machine *vm.VirtualMachine, opts options) *profile.Profiler {
+	if opts.profile == "" && opts.pprof == "" {
+		return nil
+	}
+
+	profiler := profile.NewProfiler(resolvePlatform(opts).LoadAddress)
+	machine.SetProfiler(profiler)
+
+	return profiler
+}
+
+func saveProfile(profiler *profile.Profiler, opts options) {
+	if opts.profile != "" {
+		if err := profiler.Profile().Save(opts.profile); err != nil {
+			log.Printf("profile.Save(): %v\n", err)
+		}
+	}
+
+	if opts.pprof != "" {
+		if err := profiler.SavePprof(opts.pprof); err != nil {
+			log.Printf("profiler.SavePprof(): %v\n", err)
+		}
+	}
+
+	if err := profiler.Report(os.Stdout); err != nil {
+		log.Printf("profiler.Report(): %v\n", err)
+	}
+}
+
+func newCoverage(machine *vm.VirtualMachine, buffer []byte, opts options) *coverage.Coverage {
+	if opts.coverage == "" {
+		return nil
+	}
+
+	fname, err := filepath.Abs(opts.fname)
+	if err != nil {
+		fname = opts.fname
+	}
+
+	c := coverage.New(fname, rom.SHA1(buffer), resolvePlatform(opts).LoadAddress)
+	machine.SetCoverage(c)
+
+	return c
+}
+
+func saveCoverage(c *coverage.Coverage, opts options) {
+	if err := c.Save(opts.coverage); err != nil {
+		log.Printf("coverage.Save(): %v\n", err)
+	}
 }